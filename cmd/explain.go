@@ -0,0 +1,53 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os/signal"
+	"syscall"
+
+	"github.com/gophergala2016/reviewer/reviewer"
+	"github.com/spf13/cobra"
+)
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain owner/repo#number",
+	Short: "Explain the decision taken for a pull request",
+	Long: `Evaluates a single pull request as reviewer would, without merging it,
+and prints every step taken: the effective policy, each vote found in
+the comments and whether it was counted, its mergeability, each status
+context, and the final decision.
+
+On SIGINT or SIGTERM it aborts right away.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			exit(reviewer.ConfigError{Err: errors.New("Expected a single pull request, as owner/repo#number")})
+		}
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		if Timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, Timeout)
+			defer cancel()
+		}
+		exit(reviewer.Explain(ctx, args[0]))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(explainCmd)
+}
@@ -15,8 +15,10 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/gophergala2016/reviewer/reviewer"
 	"github.com/spf13/cobra"
@@ -25,9 +27,33 @@ import (
 
 var cfgFile string
 
+// LogLevel defines the minimum level of the diagnostics written to stderr.
+var LogLevel string
+
+// LogFormat defines the format of the diagnostics, text or json.
+var LogFormat string
+
 // DryRun defines whether the program must actually act, or just give feedback as acting.
 var DryRun bool
 
+// PublishStatus defines whether the commit status must be published even in dry-run mode.
+var PublishStatus bool
+
+// Output defines the format of the report.
+var Output string
+
+// ReportFile defines the file the report is written to, instead of stdout.
+var ReportFile string
+
+// FailOnNOP defines whether the program must fail when some pull requests aren't merged.
+var FailOnNOP bool
+
+// Timeout defines the deadline for the whole run, or for each evaluation when serving or receiving webhooks.
+var Timeout time.Duration
+
+// Repos defines the repositories, or pull requests, to be processed instead of all the configured ones.
+var Repos []string
+
 type config struct {
 	Authorization struct {
 		Token string
@@ -41,21 +67,66 @@ type config struct {
 
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
-	Use:   "reviewer",
+	Use:   "reviewer [owner/repo[#number]]...",
 	Short: "Code review your pull requests",
 	Long: `By running reviewer your repo's pull requests will get merged
-according to the configuration file.`,
+according to the configuration file.
+
+Only the repositories, or single pull requests, given as arguments or
+through --repo are processed, when any.
+
+On SIGINT or SIGTERM it stops after finishing the pull request being
+processed, and aborts right away on the second one.
+
+Exit codes: 0 on success, 1 when some repositories or pull requests
+couldn't be processed, 2 on configuration errors, 3 on authentication
+errors, and 4 with --fail-on-nop when some pull requests weren't merged.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		reviewer.Execute(DryRun)
+		options, err := rootOptions(args)
+		if err != nil {
+			exit(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		options.Stop = reviewer.StopOnSignal(cancel)
+		exit(reviewer.Execute(ctx, options))
 	},
 }
 
+// rootOptions returns the options of the run from the flags and the arguments, or a ConfigError if they're wrong.
+func rootOptions(args []string) (reviewer.Options, error) {
+	targets, err := reviewer.ParseTargets(append(Repos, args...))
+	if err != nil {
+		return reviewer.Options{}, reviewer.ConfigError{Err: err}
+	}
+	if err := reviewer.CheckOutput(Output); err != nil {
+		return reviewer.Options{}, reviewer.ConfigError{Err: err}
+	}
+	return reviewer.Options{
+		DryRun:        DryRun,
+		PublishStatus: PublishStatus,
+		Targets:       targets,
+		Output:        Output,
+		ReportFile:    ReportFile,
+		FailOnNOP:     FailOnNOP,
+		Timeout:       Timeout,
+	}, nil
+}
+
+// exit prints the error returned by a command, if any, and exits with its exit code.
+func exit(err error) {
+	if err != nil {
+		reviewer.Log.Errorf("%v", err)
+	}
+	os.Exit(reviewer.ExitCode(err))
+}
+
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(-1)
+		// the command line is wrong, cobra already printed why
+		os.Exit(reviewer.ExitConfigError)
 	}
 }
 
@@ -67,24 +138,42 @@ func init() {
 	// will be global for your application.
 
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.reviewer.yaml)")
+	RootCmd.PersistentFlags().StringVar(&LogLevel, "log-level", "info", "Minimum level of the diagnostics written to stderr: debug, info, warn or error. Default: info.")
+	RootCmd.PersistentFlags().StringVar(&LogFormat, "log-format", reviewer.LogText, "Format of the diagnostics: text or json. Default: text.")
+	RootCmd.PersistentFlags().DurationVar(&Timeout, "timeout", 0, "Deadline for the whole run, or for each evaluation with serve and webhook, e.g. 5m. Default: none.")
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	RootCmd.Flags().BoolVarP(&DryRun, "dry-run", "d", false, "Won't merge if enabled. Default: disabled.")
+	RootCmd.Flags().BoolVar(&PublishStatus, "publish-status", false, "Publishes the reviewer/score commit status even in dry-run mode. Default: disabled.")
+	RootCmd.Flags().StringVarP(&Output, "output", "o", reviewer.OutputText, "Format of the report: text, json, jsonl, markdown, html or junit. Default: text.")
+	RootCmd.Flags().StringVar(&ReportFile, "report-file", "", "Writes the report to the file instead of stdout. Default: stdout.")
+	RootCmd.Flags().BoolVar(&FailOnNOP, "fail-on-nop", false, "Exits with code 4 when some pull requests weren't merged, for CI usage. Default: disabled.")
+	RootCmd.Flags().StringSliceVarP(&Repos, "repo", "r", []string{}, "Repository, as owner/repo, or pull request, as owner/repo#number, to process. Can be repeated. Default: all.")
 }
 
-// initConfig reads in config file and ENV variables if set.
+// initConfig sets up the logger, and reads in config file and ENV variables if set.
 func initConfig() {
+	level, err := reviewer.ParseLevel(LogLevel)
+	if err == nil && LogFormat != reviewer.LogText && LogFormat != reviewer.LogJSON {
+		err = fmt.Errorf("Unknown log format %q, expected text or json", LogFormat)
+	}
+	if err != nil {
+		exit(reviewer.ConfigError{Err: err})
+	}
+	reviewer.Log = reviewer.NewLogger(os.Stderr, level, LogFormat)
+
 	if cfgFile != "" { // enable ability to specify config file via flag
 		viper.SetConfigFile(cfgFile)
+	} else {
+		viper.SetConfigName(".reviewer") // name of config file (without extension)
+		viper.AddConfigPath("$HOME")     // adding home directory as first search path
 	}
 
-	viper.SetConfigName(".reviewer") // name of config file (without extension)
-	viper.AddConfigPath("$HOME")     // adding home directory as first search path
-	viper.SetEnvPrefix("reviewer")   // so viper.AutomaticEnv will get matching envvars starting with REVIEWER_
-	viper.AutomaticEnv()             // read in environment variables that match
+	viper.SetEnvPrefix("reviewer") // so viper.AutomaticEnv will get matching envvars starting with REVIEWER_
+	viper.AutomaticEnv()           // read in environment variables that match
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		reviewer.Log.Infof("Using config file: %v", viper.ConfigFileUsed())
 	}
 }
@@ -0,0 +1,67 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gophergala2016/reviewer/reviewer"
+	"github.com/spf13/viper"
+)
+
+func TestRootOptions(t *testing.T) {
+	Repos, Output = []string{"cooldeveloper/mycoolapp"}, reviewer.OutputJSON
+	defer func() { Repos, Output = []string{}, reviewer.OutputText }()
+
+	options, err := rootOptions([]string{"cooldeveloper/mycoolapi#47"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(options.Targets) != 2 || options.Targets[1].Number != 47 || options.Output != reviewer.OutputJSON {
+		t.Fatalf("Bad options %+v", options)
+	}
+
+	if _, err = rootOptions([]string{"mycoolapp"}); reviewer.ExitCode(err) != reviewer.ExitConfigError {
+		t.Errorf("Invalid targets should exit with %v, got %v", reviewer.ExitConfigError, err)
+	}
+	Output = "yaml"
+	if _, err = rootOptions(nil); reviewer.ExitCode(err) != reviewer.ExitConfigError {
+		t.Errorf("An unknown output should exit with %v, got %v", reviewer.ExitConfigError, err)
+	}
+}
+
+func TestInitConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reviewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfgFile = filepath.Join(dir, "reviewer.yml")
+	defer func() { cfgFile = "" }()
+	if err := ioutil.WriteFile(cfgFile, []byte("repositories:\n  mycoolapp:\n    username: cooldeveloper\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	initConfig()
+	if used := viper.ConfigFileUsed(); used != cfgFile {
+		t.Fatalf("Using config file %q (expected %q)", used, cfgFile)
+	}
+	if username := viper.GetString("repositories.mycoolapp.username"); username != "cooldeveloper" {
+		t.Fatalf("Bad username %q read from the config file", username)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/gophergala2016/reviewer/reviewer"
+	"github.com/spf13/cobra"
+)
+
+// Interval defines how often the repositories are evaluated when serving.
+var Interval time.Duration
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Keep evaluating your pull requests periodically",
+	Long: `Runs reviewer as a long running daemon, evaluating the configured
+repositories every interval, with some jitter, using a single GitHub client.
+
+The config file is reloaded when it changes on disk. On SIGINT or SIGTERM,
+it stops after finishing the pull request being processed. --timeout is the
+deadline for each evaluation of the repositories.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		exit(reviewer.Serve(context.Background(), reviewer.Options{
+			DryRun:        DryRun,
+			PublishStatus: PublishStatus,
+			Timeout:       Timeout,
+		}, Interval))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().DurationVar(&Interval, "interval", 5*time.Minute, "Time between evaluations. Default: 5m.")
+	serveCmd.Flags().BoolVarP(&DryRun, "dry-run", "d", false, "Won't merge if enabled. Default: disabled.")
+	serveCmd.Flags().BoolVar(&PublishStatus, "publish-status", false, "Publishes the reviewer/score commit status even in dry-run mode. Default: disabled.")
+}
@@ -0,0 +1,58 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/gophergala2016/reviewer/reviewer"
+	"github.com/spf13/cobra"
+)
+
+// Listen defines the address the webhook receiver listens on.
+var Listen string
+
+// webhookCmd represents the webhook command
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Evaluate your pull requests when GitHub notifies changes",
+	Long: `Listens for GitHub webhook events, and re-evaluates just the pull request
+affected by each pull_request, issue_comment, pull_request_review, status and
+check_suite event, as reviewer does when run directly.
+
+Events are validated with the X-Hub-Signature-256 header, using the secret
+configured in webhook.secret. The affected pull requests are queued, each
+once however many events arrive for it, and processed one at a time.
+
+On SIGINT or SIGTERM, it stops listening and exits after finishing the pull
+request being processed. --timeout is the deadline for processing each batch
+of queued pull requests.`,
+	Example: "  reviewer webhook --listen :8080",
+	Run: func(cmd *cobra.Command, args []string) {
+		exit(reviewer.Webhook(context.Background(), Listen, reviewer.Options{
+			DryRun:        DryRun,
+			PublishStatus: PublishStatus,
+			Timeout:       Timeout,
+		}))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(webhookCmd)
+
+	webhookCmd.Flags().StringVar(&Listen, "listen", ":8080", "Address to listen on for events. Default: :8080.")
+	webhookCmd.Flags().BoolVarP(&DryRun, "dry-run", "d", false, "Won't merge if enabled. Default: disabled.")
+	webhookCmd.Flags().BoolVar(&PublishStatus, "publish-status", false, "Publishes the reviewer/score commit status even in dry-run mode. Default: disabled.")
+}
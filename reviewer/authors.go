@@ -0,0 +1,175 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// associations are the GitHub author associations which can be used in author rules.
+var associations = map[string]bool{
+	"OWNER":                  true,
+	"MEMBER":                 true,
+	"COLLABORATOR":           true,
+	"CONTRIBUTOR":            true,
+	"FIRST_TIME_CONTRIBUTOR": true,
+	"FIRST_TIMER":            true,
+	"NONE":                   true,
+}
+
+// AuthorRules contains the rules for the authors whose pull requests can be merged automatically.
+// Each rule is a login, a team as org/team, or an author association such as FIRST_TIME_CONTRIBUTOR.
+type AuthorRules struct {
+	Allow []string // when set, only authors matching any of them are allowed, nobody if it's empty
+	Deny  []string // authors matching any of them are never allowed
+}
+
+// matchesAuthor returns true if the author matches the rule.
+func matchesAuthor(ctx context.Context, client *GHClient, rule string, login string, association string) (bool, error) {
+	if associations[rule] {
+		return rule == association, nil
+	}
+	if strings.Contains(rule, "/") {
+		parts := strings.SplitN(strings.TrimPrefix(rule, "@"), "/", 2)
+		return IsTeamMember(ctx, client, parts[0], parts[1], login)
+	}
+	return strings.EqualFold(rule, login), nil
+}
+
+// CheckAuthor returns why the author's pull requests must not be merged according to the rules, or an empty string if they can.
+func CheckAuthor(ctx context.Context, client *GHClient, rules AuthorRules, login string, association string) (string, error) {
+	for _, rule := range rules.Deny {
+		matched, err := matchesAuthor(ctx, client, rule, login, association)
+		if err != nil {
+			return "", err
+		}
+		if matched {
+			return fmt.Sprintf("Author %v denied (%v)", login, rule), nil
+		}
+	}
+	if rules.Allow == nil {
+		return "", nil
+	}
+	for _, rule := range rules.Allow {
+		matched, err := matchesAuthor(ctx, client, rule, login, association)
+		if err != nil {
+			return "", err
+		}
+		if matched {
+			return "", nil
+		}
+	}
+	return fmt.Sprintf("Author %v not allowed", login), nil
+}
+
+// IsTeamMember returns true if the user is a member of the organization's team, given by its slug or name.
+func IsTeamMember(ctx context.Context, client *GHClient, org string, team string, login string) (bool, error) {
+	options := &github.ListOptions{PerPage: 100}
+	for {
+		teams, response, err := client.Teams.ListTeams(ctx, org, options)
+		if err != nil {
+			return false, err
+		}
+		for _, t := range teams {
+			if t.Slug == nil || !(strings.EqualFold(*t.Slug, team) || (t.Name != nil && strings.EqualFold(*t.Name, team))) {
+				continue
+			}
+			membership, response, err := client.Teams.GetTeamMembershipBySlug(ctx, org, *t.Slug, login)
+			if response != nil && response.StatusCode == http.StatusNotFound {
+				return false, nil
+			}
+			if err != nil {
+				return false, err
+			}
+			return membership.State != nil && *membership.State == "active", nil
+		}
+		if response == nil || response.NextPage == 0 {
+			break
+		}
+		options.Page = response.NextPage
+	}
+	return false, fmt.Errorf("Team %v/%v not found", org, team)
+}
+
+// coAuthorPattern matches the Co-authored-by trailers of commit messages.
+var coAuthorPattern = regexp.MustCompile(`(?mi)^Co-authored-by:\s*(.*?)\s*<([^>]*)>\s*$`)
+
+// noReplyPattern matches GitHub's no-reply email addresses, which contain the login.
+var noReplyPattern = regexp.MustCompile(`(?i)^(?:\d+\+)?([^@]+)@users\.noreply\.github\.com$`)
+
+// coAuthors returns the logins of the co-authors in the commit message,
+// taken from their no-reply email address when possible, or from their name otherwise.
+func coAuthors(message string) []string {
+	logins := []string{}
+	for _, match := range coAuthorPattern.FindAllStringSubmatch(message, -1) {
+		if login := noReplyPattern.FindStringSubmatch(match[2]); login != nil {
+			logins = append(logins, login[1])
+		} else if match[1] != "" {
+			logins = append(logins, match[1])
+		}
+	}
+	return logins
+}
+
+// LoadAliases returns the groups of logins belonging to the same person, keyed by any of them.
+func LoadAliases(config ConfigReader) [][]string {
+	aliases := [][]string{}
+	for _, name := range config.AllKeys() {
+		aliases = append(aliases, append([]string{name}, config.GetStringSlice(name)...))
+	}
+	return aliases
+}
+
+// excludedVoters returns the logins, lowercased, whose votes don't count with the reason:
+// the author, the authors and co-authors of the commits, and their aliases.
+func excludedVoters(author string, commits []*github.RepositoryCommit, aliases [][]string) map[string]string {
+	excluded := make(map[string]string)
+	exclude := func(login string, reason string) {
+		login = strings.ToLower(login)
+		if _, exists := excluded[login]; !exists && login != "" {
+			excluded[login] = reason
+		}
+	}
+
+	exclude(author, VoteSelf)
+	for _, commit := range commits {
+		exclude(getLogin(commit.Author), VoteCoAuthor)
+		if commit.Commit != nil && commit.Commit.Message != nil {
+			for _, login := range coAuthors(*commit.Commit.Message) {
+				exclude(login, VoteCoAuthor)
+			}
+		}
+	}
+	for _, group := range aliases {
+		aliased := false
+		for _, login := range group {
+			if _, exists := excluded[strings.ToLower(login)]; exists {
+				aliased = true
+			}
+		}
+		if aliased {
+			for _, login := range group {
+				exclude(login, VoteAlias)
+			}
+		}
+	}
+	return excluded
+}
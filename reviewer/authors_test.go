@@ -0,0 +1,115 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"github.com/google/go-github/v32/github"
+	"net/http"
+	"testing"
+)
+
+// mockTeamsService is a mock for github.TeamsService.
+type mockTeamsService struct {
+	members map[string]bool
+}
+
+// mockTeamsService's ListTeams implementation, returning a page of other teams before the core one.
+func (m *mockTeamsService) ListTeams(ctx context.Context, org string, opt *github.ListOptions) ([]*github.Team, *github.Response, error) {
+	if opt == nil || opt.Page < 2 {
+		slug := "other"
+		return []*github.Team{{Slug: &slug}}, &github.Response{NextPage: 2}, nil
+	}
+	slug := "core"
+	return []*github.Team{{Slug: &slug}}, &github.Response{}, nil
+}
+
+// mockTeamsService's GetTeamMembershipBySlug implementation.
+func (m *mockTeamsService) GetTeamMembershipBySlug(ctx context.Context, org string, slug string, user string) (*github.Membership, *github.Response, error) {
+	if !m.members[user] {
+		response := &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+		return nil, response, &github.ErrorResponse{Response: response.Response, Message: "Not Found"}
+	}
+	state := "active"
+	return &github.Membership{State: &state}, nil, nil
+}
+
+func TestCheckAuthor(t *testing.T) {
+	client := newMockGHClient(nil, nil)
+	client.Teams = &mockTeamsService{members: map[string]bool{"alice": true}}
+	rules := AuthorRules{
+		Allow: []string{"myorg/core", "bob", "MEMBER"},
+		Deny:  []string{"mallory", "FIRST_TIME_CONTRIBUTOR"},
+	}
+
+	testAuthor := func(login string, association string, allowed bool) {
+		reason, err := CheckAuthor(context.Background(), client, rules, login, association)
+		if err != nil {
+			t.Fatalf("CheckAuthor returned error(%s)", err)
+		}
+		if (reason == "") != allowed {
+			t.Fatalf("Author %v (%v) should have allowed %v, got %q", login, association, allowed, reason)
+		}
+	}
+
+	testAuthor("alice", "CONTRIBUTOR", true)
+	testAuthor("bob", "NONE", true)
+	testAuthor("carol", "MEMBER", true)
+	testAuthor("dave", "CONTRIBUTOR", false)
+	testAuthor("mallory", "MEMBER", false)
+	testAuthor("bob", "FIRST_TIME_CONTRIBUTOR", false)
+
+	if reason, _ := CheckAuthor(context.Background(), client, AuthorRules{}, "anyone", "NONE"); reason != "" {
+		t.Fatal("Without rules every author should be allowed")
+	}
+	if reason, _ := CheckAuthor(context.Background(), client, AuthorRules{Allow: []string{}}, "anyone", "OWNER"); reason == "" {
+		t.Fatal("An empty allow list should allow nobody")
+	}
+	if _, err := CheckAuthor(context.Background(), client, AuthorRules{Allow: []string{"myorg/unknown"}}, "alice", "NONE"); err == nil {
+		t.Fatal("An unknown team should return error")
+	}
+}
+
+func newMockCommit(login string, message string) *github.RepositoryCommit {
+	return &github.RepositoryCommit{
+		Author: &github.User{Login: &login},
+		Commit: &github.Commit{Message: &message},
+	}
+}
+
+func TestExcludedVoters(t *testing.T) {
+	commits := []*github.RepositoryCommit{
+		newMockCommit("author", "Initial commit"),
+		newMockCommit("Helper", "Fix tests\n\nCo-authored-by: Pair Programmer <12345+pair@users.noreply.github.com>\nCo-authored-by: carol <carol@example.com>"),
+	}
+	aliases := [][]string{{"author", "author-bot"}, {"dave", "dave-bot"}}
+
+	excluded := excludedVoters("author", commits, aliases)
+	expected := map[string]string{
+		"author":     VoteSelf,
+		"helper":     VoteCoAuthor,
+		"pair":       VoteCoAuthor,
+		"carol":      VoteCoAuthor,
+		"author-bot": VoteAlias,
+	}
+	if len(excluded) != len(expected) {
+		t.Fatalf("Bad excluded voters %v (expected %v)", excluded, expected)
+	}
+	for login, reason := range expected {
+		if excluded[login] != reason {
+			t.Fatalf("Voter %v excluded for %q (expected %q)", login, excluded[login], reason)
+		}
+	}
+}
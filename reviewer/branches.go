@@ -0,0 +1,76 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// Filter selects the pull requests to be evaluated.
+type Filter struct {
+	WIP     *regexp.Regexp // titles of work in progress pull requests
+	Include []string       // base branch globs to evaluate, all when empty
+	Exclude []string       // base branch globs not to evaluate
+}
+
+// MatchesBranch returns true if the base branch is included and not excluded.
+func (f Filter) MatchesBranch(branch string) bool {
+	for _, pattern := range f.Exclude {
+		if matched, _ := path.Match(pattern, branch); matched {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, pattern := range f.Include {
+		if matched, _ := path.Match(pattern, branch); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// listOptions returns the options for listing the pull requests, filtering by base branch in GitHub when there's a single literal one included.
+func (f Filter) listOptions() *github.PullRequestListOptions {
+	if len(f.Include) != 1 || strings.ContainsAny(f.Include[0], "*?[\\") {
+		return nil
+	}
+	return &github.PullRequestListOptions{Base: f.Include[0]}
+}
+
+// MatchBranch returns the pattern best matching the branch: the branch itself if present, or the longest matching glob otherwise,
+// the first one in alphabetical order among equally long ones, so the result doesn't depend on the order of the patterns.
+func MatchBranch(patterns []string, branch string) (string, bool) {
+	for _, pattern := range patterns {
+		if pattern == branch {
+			return branch, true
+		}
+	}
+	best := ""
+	found := false
+	for _, pattern := range patterns {
+		matched, _ := path.Match(pattern, branch)
+		if matched && (!found || len(pattern) > len(best) || len(pattern) == len(best) && pattern < best) {
+			best = pattern
+			found = true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,79 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"testing"
+)
+
+func TestMatchesBranch(t *testing.T) {
+	filter := Filter{
+		Include: []string{"develop", "feature/*"},
+		Exclude: []string{"feature/old-*"},
+	}
+
+	testMatch := func(branch string, expected bool) {
+		if filter.MatchesBranch(branch) != expected {
+			t.Fatalf("Branch %v should match %v", branch, expected)
+		}
+	}
+
+	testMatch("develop", true)
+	testMatch("feature/login", true)
+	testMatch("feature/old-login", false)
+	testMatch("main", false)
+	testMatch("release/1.0", false)
+
+	if !(Filter{}).MatchesBranch("main") {
+		t.Fatal("Without includes, every branch should match")
+	}
+}
+
+func TestMatchBranch(t *testing.T) {
+	patterns := []string{"*", "release/*", "release/1.0"}
+
+	testMatch := func(branch string, expected string) {
+		pattern, found := MatchBranch(patterns, branch)
+		if !found || pattern != expected {
+			t.Fatalf("Branch %v matched %v (expected %v)", branch, pattern, expected)
+		}
+	}
+
+	testMatch("release/1.0", "release/1.0")
+	testMatch("release/2.0", "release/*")
+	testMatch("develop", "*")
+
+	for _, patterns := range [][]string{{"release/1.?", "release/*.0"}, {"release/*.0", "release/1.?"}} {
+		if pattern, _ := MatchBranch(patterns, "release/1.0"); pattern != "release/*.0" {
+			t.Fatalf("Equally long globs %v matched %v (expected release/*.0)", patterns, pattern)
+		}
+	}
+	if _, found := MatchBranch([]string{"release/*"}, "develop"); found {
+		t.Fatal("Branch develop shouldn't match release/*")
+	}
+}
+
+func TestFilterListOptions(t *testing.T) {
+	opt := Filter{Include: []string{"develop"}}.listOptions()
+	if opt == nil || opt.Base != "develop" {
+		t.Fatal("A single literal branch should be filtered by GitHub")
+	}
+	if (Filter{Include: []string{"feature/*"}}).listOptions() != nil {
+		t.Fatal("A glob can't be filtered by GitHub")
+	}
+	if (Filter{Include: []string{"develop", "main"}}).listOptions() != nil {
+		t.Fatal("Several branches can't be filtered by GitHub")
+	}
+}
@@ -18,7 +18,9 @@ import (
 	"errors"
 	"fmt"
 	"github.com/spf13/viper"
-	"log"
+	"sort"
+	"strings"
+	"time"
 )
 
 // ConfigRepositoriesChecker is an interface for checking Viper's keys or getting their values
@@ -59,11 +61,31 @@ func (c *Config) GetInt(key string) int {
 	return c.config.GetInt(key)
 }
 
+// GetDuration returns the value associated with the key as a duration
+func (c *Config) GetDuration(key string) time.Duration {
+	return c.config.GetDuration(key)
+}
+
 // GetStringSlice returns the value associated with the key as an slice of strings
 func (c *Config) GetStringSlice(key string) []string {
 	return c.config.GetStringSlice(key)
 }
 
+// IsSet returns true if the key is set
+func (c *Config) IsSet(key string) bool {
+	return c.config.IsSet(key)
+}
+
+// GetStringMap returns the value associated with the key as a map of interfaces
+func (c *Config) GetStringMap(key string) map[string]interface{} {
+	return c.config.GetStringMap(key)
+}
+
+// GetStringMapString returns the value associated with the key as a map of strings
+func (c *Config) GetStringMapString(key string) map[string]string {
+	return c.config.GetStringMapString(key)
+}
+
 // IsSet contains the function used to check if key is set
 var IsSet = viper.IsSet
 
@@ -71,26 +93,36 @@ var IsSet = viper.IsSet
 var ConfigFileUsed = viper.ConfigFileUsed
 
 // Configure runs all the checks needed for the config file to be set up correctly and prints the repositories available
-func Configure() {
+// It returns a ConfigError when a check fails.
+func Configure() error {
 
 	err := CheckFile()
 	if err != nil {
-		log.Fatal(err)
+		return ConfigError{err}
 	}
 
 	err = CheckRepositories()
 	if err != nil {
-		log.Fatal(err)
+		return ConfigError{err}
 	}
 
 	config := NewConfig(viper.Sub("repositories"))
 	resp, err2 := CheckRepositoriesData(config)
 	if err2 != nil {
-		log.Fatal(err2)
+		return ConfigError{err2}
 	}
 
-	fmt.Printf("%s", resp)
+	fmt.Fprintf(Stdout, "%s", resp)
 
+	fmt.Fprintf(Stdout, "Policies:\n")
+	for _, repoName := range RepositoryNames(config) {
+		policy, err := LoadPolicy(config, repoName)
+		if err != nil {
+			return ConfigError{err}
+		}
+		fmt.Fprintf(Stdout, "%s", PolicyTable(repoName, policy))
+	}
+	return nil
 }
 
 // CheckFile checks if the configuration file exists and is not empty
@@ -110,12 +142,27 @@ func CheckRepositories() error {
 	return nil
 }
 
+// RepositoryNames returns the names of the configured repositories, sorted.
+// Viper returns the leaf keys, like "myrepo.username", so the names are the keys' first parts.
+func RepositoryNames(config ConfigRepositoriesChecker) []string {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, key := range config.AllKeys() {
+		name := strings.SplitN(key, ".", 2)[0]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // CheckRepositoriesData checks if all the repositories have all the parameters set
 func CheckRepositoriesData(config ConfigRepositoriesChecker) (s string, err error) {
 
-	keys := config.AllKeys()
 	var response = ""
-	for _, v := range keys {
+	for _, v := range RepositoryNames(config) {
 		username := config.GetString(v + ".username")
 		status := config.GetString(v + ".status")
 		required := config.GetString(v + ".required")
@@ -15,7 +15,6 @@
 package reviewer
 
 import (
-	reviewer "."
 	"testing"
 )
 
@@ -69,32 +68,32 @@ func mockIsSet2(a string) bool {
 }
 
 func TestCheckFile(t *testing.T) {
-	reviewer.ConfigFileUsed = mockConfigFileUsed
+	ConfigFileUsed = mockConfigFileUsed
 
-	err := reviewer.CheckFile()
+	err := CheckFile()
 	if err == nil {
 		t.Fatal("Without configuration file or empty, it should complain")
 	}
 
-	reviewer.ConfigFileUsed = mockConfigFileUsed2
+	ConfigFileUsed = mockConfigFileUsed2
 
-	err = reviewer.CheckFile()
+	err = CheckFile()
 	if err != nil {
 		t.Fatal("With configuration file not empty, it should not complain")
 	}
 }
 
 func TestCheckRepositories(t *testing.T) {
-	reviewer.IsSet = mockIsSet
+	IsSet = mockIsSet
 
-	err := reviewer.CheckRepositories()
+	err := CheckRepositories()
 	if err == nil {
 		t.Fatal("Without Repositories tag or without any repository set, it should complain")
 	}
 
-	reviewer.IsSet = mockIsSet2
+	IsSet = mockIsSet2
 
-	err = reviewer.CheckRepositories()
+	err = CheckRepositories()
 	if err != nil {
 		t.Fatal("With Repositories tag and repositories set, it should not complain")
 	}
@@ -103,7 +102,7 @@ func TestCheckRepositories(t *testing.T) {
 func TestCheckRepositoriesData(t *testing.T) {
 	config := newMockConfig("test")
 
-	response, err := reviewer.CheckRepositoriesData(config)
+	response, err := CheckRepositoriesData(config)
 	if err != nil {
 		t.Fatal("With parameters set not return error")
 	}
@@ -114,7 +113,7 @@ func TestCheckRepositoriesData(t *testing.T) {
 
 	config = newMockConfig("test2")
 
-	response, err = reviewer.CheckRepositoriesData(config)
+	response, err = CheckRepositoriesData(config)
 	if err == nil {
 		t.Fatal("With parameters not set it should return error")
 	}
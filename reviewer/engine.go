@@ -0,0 +1,141 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// Settings are the typed settings of an Engine, which doesn't read the configuration file.
+type Settings struct {
+	MaxMerges     int             // merges across repositories, 0 for no limit
+	DryRun        bool            // Apply won't merge, nor publish anything but the commit status with PublishStatus
+	PublishStatus bool            // Apply publishes the commit status even in dry-run mode
+	Policies      []Policy        // checked in order by Evaluate, DefaultPolicies when nil
+	Stop          <-chan struct{} // Evaluate stops between pull requests when closed
+}
+
+// Engine evaluates the pull requests of repositories, each with its own typed policy,
+// and applies the decisions, keeping count of the merges done.
+type Engine struct {
+	client     *GHClient
+	settings   Settings
+	merges     int
+	repoMerges map[string]int
+}
+
+// NewEngine returns an engine working with the client and the settings.
+func NewEngine(client *GHClient, settings Settings) *Engine {
+	if settings.Policies == nil {
+		settings.Policies = DefaultPolicies()
+	}
+	return &Engine{
+		client:     client,
+		settings:   settings,
+		repoMerges: map[string]int{},
+	}
+}
+
+// Evaluate decides what to do with the open pull requests of the repository, or with the ones with the given numbers,
+// according to its policy. Failures evaluating a single pull request are in its decision.
+// It returns the decisions made so far when the context is done, or settings.Stop is closed.
+func (e *Engine) Evaluate(ctx context.Context, repository Repository, numbers ...int) ([]Decision, error) {
+	var pullRequests []*github.PullRequest
+	var err error
+	if len(numbers) > 0 {
+		pullRequests, err = getSelectedPullRequests(ctx, e.client, repository.Owner, repository.Name, numbers, repository.Filter)
+	} else {
+		pullRequests, err = listPullRequests(ctx, e.client, repository.Owner, repository.Name, repository.Filter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	decisions := make([]Decision, 0, len(pullRequests))
+	for _, pullRequest := range pullRequests {
+		if ctx.Err() != nil {
+			return decisions, ctx.Err()
+		}
+		if stopping(e.settings.Stop) {
+			return decisions, nil
+		}
+		decisions = append(decisions, Evaluate(ctx, e.client, repository, pullRequest, e.settings.Policies, Now()))
+	}
+	return decisions, nil
+}
+
+// Apply publishes the decision's commit status, summary comment and labels, as configured, and merges the pull request
+// when decided so, unless in dry-run mode or the limits of merges are reached, which defers it.
+// It returns what was done, and the failures found, as a RunError, or an AuthError if GitHub rejected the credentials.
+func (e *Engine) Apply(ctx context.Context, decision Decision) (Result, error) {
+	result := Result{Decision: decision}
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	repository := decision.Repository
+	where := fmt.Sprintf("%v/%v#%v", repository.Owner, repository.Name, decision.Number)
+	var failures []error
+	if decision.Err != nil {
+		failures = append(failures, failure(decision.Err, "%v Failure evaluating", where))
+	}
+	key := repository.Owner + "/" + repository.Name
+	if decision.Action == ActionMerge && (!WithinLimit(e.settings.MaxMerges, e.merges) || !WithinLimit(repository.Policy.MaxMerges, e.repoMerges[key])) {
+		result.Action = ActionDeferred
+		result.Reason += ", max_merges_per_run reached"
+		result.Code = ReasonMaxMerges
+	}
+	failures = append(failures, publish(ctx, e.client, result.Decision, e.settings)...)
+	if result.Action != ActionMerge {
+		return result, applyError(failures)
+	}
+	e.merges++
+	e.repoMerges[key]++
+	if e.settings.DryRun {
+		return result, applyError(failures)
+	}
+
+	merge, err := Merge(ctx, e.client, repository.Owner, repository.Name, decision.Number, decision.Rules.MergeMethod)
+	if err != nil {
+		result.MergeErr = err
+		failures = append(failures, failure(err, "%v Merge failed", where))
+		return result, applyError(failures)
+	}
+	result.Merged = true
+	if merge != nil && merge.SHA != nil {
+		result.MergeSHA = *merge.SHA
+	}
+	if len(repository.Labels) > 0 {
+		err = SyncLabels(ctx, e.client, repository.Owner, repository.Name, decision.Number, repository.Labels, []string{LabelMerged})
+		if err != nil {
+			failures = append(failures, failure(err, "%v Failure updating labels", where))
+		}
+	}
+	return result, applyError(failures)
+}
+
+// applyError returns the failures found applying a decision as an error, the AuthError when there's one.
+func applyError(failures []error) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	for _, err := range failures {
+		if IsAuthError(err) {
+			return err
+		}
+	}
+	return RunError{failures}
+}
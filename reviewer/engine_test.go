@@ -0,0 +1,108 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"errors"
+	"github.com/google/go-github/v32/github"
+	"regexp"
+	"testing"
+)
+
+func TestEngineEvaluate(t *testing.T) {
+	number, title := 1, "WIP: Adds engine"
+	client := newMockGHClient([]*github.PullRequest{{Number: &number, Title: &title}}, [][]*github.IssueComment{{}})
+	repository := Repository{Owner: "user", Name: "repo", Filter: Filter{WIP: regexp.MustCompile(DefaultWIPPattern)}}
+
+	engine := NewEngine(client, Settings{})
+	decisions, err := engine.Evaluate(context.Background(), repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 1 || decisions[0].Action != ActionSkip || decisions[0].Repository.Name != "repo" {
+		t.Fatalf("Expected the work in progress pull request skipped, got %v", decisions)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	decisions, err = engine.Evaluate(ctx, repository)
+	if err != context.Canceled || len(decisions) != 0 {
+		t.Fatalf("Evaluating with a cancelled context should stop, got %v %v", decisions, err)
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	decisions, err = NewEngine(client, Settings{Stop: stop}).Evaluate(context.Background(), repository)
+	if err != nil || len(decisions) != 0 {
+		t.Fatalf("Evaluating once stopped should stop before the next pull request, got %v %v", decisions, err)
+	}
+}
+
+func TestEngineEvaluateSelected(t *testing.T) {
+	open, closed := "open", "closed"
+	pullRequests := []*github.PullRequest{newMockPullRequest(1, "WIP: Adds engine", true), newMockPullRequest(2, "Merged already", true)}
+	pullRequests[0].State, pullRequests[1].State = &open, &closed
+	repository := Repository{Owner: "user", Name: "repo", Filter: Filter{WIP: regexp.MustCompile(DefaultWIPPattern)}}
+
+	engine := NewEngine(newMockGHClient(pullRequests, nil), Settings{})
+	decisions, err := engine.Evaluate(context.Background(), repository, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 1 || decisions[0].Number != 1 {
+		t.Fatalf("Expected only the open pull request evaluated, got %v", decisions)
+	}
+}
+
+func TestEngineApply(t *testing.T) {
+	engine := NewEngine(newMockGHClient(nil, nil), Settings{MaxMerges: 2, DryRun: true})
+	repository := Repository{Owner: "user", Name: "repo", Policy: RepoPolicy{MaxMerges: 1}}
+	other := Repository{Owner: "user", Name: "other"}
+	decide := func(repository Repository, number int, action string) Decision {
+		return Decision{
+			PullRequestInfo: PullRequestInfo{Number: number},
+			Repository:      repository,
+			Action:          action,
+			Reason:          "score 2 of 2 required",
+		}
+	}
+	tests := []struct {
+		decision Decision
+		expected string
+	}{
+		{decide(repository, 1, ActionNOP), ActionNOP},
+		{decide(repository, 2, ActionMerge), ActionMerge},
+		{decide(repository, 3, ActionMerge), ActionDeferred}, // repository's limit
+		{decide(other, 4, ActionMerge), ActionMerge},
+		{decide(other, 5, ActionMerge), ActionDeferred}, // run's limit
+	}
+	for _, test := range tests {
+		result, err := engine.Apply(context.Background(), test.decision)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Action != test.expected || result.Merged {
+			t.Errorf("Pull request %v applied as %v (merged %v), expected %v", test.decision.Number, result.Action, result.Merged, test.expected)
+		}
+	}
+
+	failed := decide(repository, 6, ActionNOP)
+	failed.Code, failed.Err = ReasonError, errors.New("Not Found")
+	result, err := engine.Apply(context.Background(), failed)
+	if ExitCode(err) != ExitFailure || result.Err == nil || result.MergeErr != nil {
+		t.Fatalf("Evaluation failures should be returned and kept in the result, got %v %+v", err, result)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// Exit codes of the commands.
+const (
+	ExitSuccess     = 0 // everything was processed
+	ExitFailure     = 1 // some repositories or pull requests couldn't be processed
+	ExitConfigError = 2 // the configuration is wrong
+	ExitAuthError   = 3 // GitHub rejected the credentials
+	ExitNOP         = 4 // some pull requests weren't merged, with --fail-on-nop
+)
+
+// ConfigError is an error in the configuration.
+type ConfigError struct {
+	Err error
+}
+
+func (e ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+// AuthError is an error authenticating with GitHub.
+type AuthError struct {
+	Err error
+}
+
+func (e AuthError) Error() string {
+	return fmt.Sprintf("Authentication failed: %v", e.Err)
+}
+
+// RunError is returned when some repositories or pull requests couldn't be processed.
+type RunError struct {
+	Errors []error
+}
+
+func (e RunError) Error() string {
+	lines := []string{fmt.Sprintf("%v failures:", len(e.Errors))}
+	for _, err := range e.Errors {
+		lines = append(lines, fmt.Sprintf("  %v", err))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// NOPError is returned, when asked to fail on NOP, if some pull requests weren't merged.
+type NOPError struct {
+	Count int
+}
+
+func (e NOPError) Error() string {
+	return fmt.Sprintf("%v pull requests not merged", e.Count)
+}
+
+// IsAuthError returns true if GitHub rejected the request's credentials.
+func IsAuthError(err error) bool {
+	if _, ok := err.(AuthError); ok {
+		return true
+	}
+	response, ok := err.(*github.ErrorResponse)
+	return ok && response.Response != nil && response.Response.StatusCode == http.StatusUnauthorized
+}
+
+// ExitCode returns the exit code for the error returned by a command.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+	if IsAuthError(err) {
+		return ExitAuthError
+	}
+	switch err.(type) {
+	case ConfigError:
+		return ExitConfigError
+	case NOPError:
+		return ExitNOP
+	}
+	return ExitFailure
+}
+
+// failure returns the error annotated with where it happened, or as an AuthError if GitHub rejected the credentials.
+func failure(err error, format string, a ...interface{}) error {
+	if IsAuthError(err) {
+		if _, ok := err.(AuthError); ok {
+			return err
+		}
+		return AuthError{err}
+	}
+	return fmt.Errorf(format+": %v", append(a, err)...)
+}
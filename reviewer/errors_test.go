@@ -0,0 +1,76 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"errors"
+	"github.com/google/go-github/v32/github"
+	"net/http"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	unauthorized := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusUnauthorized}, Message: "Bad credentials"}
+	notFound := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}, Message: "Not Found"}
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{nil, ExitSuccess},
+		{errors.New("boom"), ExitFailure},
+		{notFound, ExitFailure},
+		{RunError{Errors: []error{errors.New("boom")}}, ExitFailure},
+		{ConfigError{Err: errors.New("Config file not defined or empty")}, ExitConfigError},
+		{AuthError{Err: errors.New("no token")}, ExitAuthError},
+		{unauthorized, ExitAuthError},
+		{NOPError{Count: 2}, ExitNOP},
+	}
+	for _, test := range tests {
+		if code := ExitCode(test.err); code != test.expected {
+			t.Errorf("Exit code for %v should be %v, got %v", test.err, test.expected, code)
+		}
+	}
+}
+
+func TestFailure(t *testing.T) {
+	err := failure(errors.New("Not Found"), "%v/%v#%v Merge failed", "user", "repo", 1)
+	if err.Error() != "user/repo#1 Merge failed: Not Found" {
+		t.Errorf("Bad failure %q", err)
+	}
+	unauthorized := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusUnauthorized}, Message: "Bad credentials"}
+	if _, ok := failure(unauthorized, "%v/%v", "user", "repo").(AuthError); !ok {
+		t.Error("Authentication failures should be kept as AuthError")
+	}
+}
+
+func TestRunError(t *testing.T) {
+	failures := []error{errors.New("boom")}
+	if err := runError(nil, 0, Options{FailOnNOP: true}); err != nil {
+		t.Errorf("Run without failures nor NOP should succeed, got %v", err)
+	}
+	if err := runError(nil, 2, Options{}); err != nil {
+		t.Errorf("Run with NOP shouldn't fail unless asked, got %v", err)
+	}
+	if _, ok := runError(nil, 2, Options{FailOnNOP: true}).(NOPError); !ok {
+		t.Error("Run with NOP should fail when asked")
+	}
+	if _, ok := runError(failures, 2, Options{FailOnNOP: true}).(RunError); !ok {
+		t.Error("Run with failures should return them")
+	}
+	invalid := append(failures, ConfigError{Err: errors.New("user/repo: Invalid merge_method \"fast-forward\"")})
+	if err := runError(invalid, 0, Options{}); ExitCode(err) != ExitConfigError {
+		t.Errorf("Run with invalid repository settings should fail as a configuration error, got %v", err)
+	}
+}
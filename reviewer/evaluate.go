@@ -0,0 +1,266 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// Actions decided for a pull request.
+const (
+	ActionMerge    = "MERGE"
+	ActionNOP      = "NOP"
+	ActionSkip     = "SKIP"
+	ActionHold     = "HOLD"
+	ActionDeferred = "DEFERRED"
+)
+
+// Reason codes of the decisions, stable for machine-readable reports.
+const (
+	ReasonApproved     = "approved"
+	ReasonDraft        = "draft"
+	ReasonLabels       = "labels"
+	ReasonError        = "error"
+	ReasonNotMergeable = "not_mergeable"
+	ReasonTests        = "tests"
+	ReasonScore        = "score"
+	ReasonMinAge       = "min_age"
+	ReasonMaintainer   = "maintainer"
+	ReasonAuthor       = "author"
+	ReasonAutoMerge    = "auto_merge"
+	ReasonSchedule     = "schedule"
+	ReasonMaxMerges    = "max_merges"
+)
+
+// Decision is the result of evaluating a pull request, with the trace of every step taken.
+type Decision struct {
+	PullRequestInfo
+	Repository Repository
+	Rules      Rules // effective rules
+	Action     string
+	Reason     string
+	Code       string // reason code
+	Err        error  // failure evaluating it, with code ReasonError
+	TestsState string
+	Mergeable  *bool
+	Trace      []string
+
+	pullRequest *github.PullRequest
+	statuses    []*github.RepoStatus
+}
+
+// trace adds a step to the decision's trace.
+func (d *Decision) trace(format string, a ...interface{}) {
+	d.Trace = append(d.Trace, fmt.Sprintf(format, a...))
+}
+
+// check adds a gate to the decision's trace, and makes it NOP for the reason when it's the first one not passed.
+func (d *Decision) check(gate string, code string, passed bool, reason string) {
+	if passed {
+		d.trace("%v: ok", gate)
+		return
+	}
+	d.trace("%v: FAIL (%v)", gate, reason)
+	if d.Action == ActionMerge {
+		d.Action = ActionNOP
+		d.Reason = reason
+		d.Code = code
+	}
+}
+
+// fetch gets the pull request, with its mergeability, and its statuses.
+// It makes the decision NOP with the failure, and returns false, when they can't be got.
+func (d *Decision) fetch(ctx context.Context, client *GHClient) bool {
+	repository := d.Repository
+	pullRequest, _, err := client.Changes.Get(ctx, repository.Owner, repository.Name, d.Number)
+	if err != nil {
+		d.trace("pull request: %v", err)
+		d.Action, d.Reason, d.Code, d.Err = ActionNOP, "Failure getting pull request", ReasonError, err
+		return false
+	}
+	statuses, err := GetStatuses(ctx, client, pullRequest, repository.Owner, repository.Name)
+	if err != nil {
+		d.trace("statuses: %v", err)
+		d.Action, d.Reason, d.Code, d.Err = ActionNOP, err.Error(), ReasonError, err
+		return false
+	}
+	d.pullRequest = pullRequest
+	d.statuses = statuses
+	d.Mergeable = pullRequest.Mergeable
+	d.TestsState = CombineStatuses(statuses, d.Rules.Contexts)
+	return true
+}
+
+// commentLine returns the vote cast in the comment, if any, and whether it was counted or why not, in a single line.
+func commentLine(comment *github.IssueComment, votes []Vote) string {
+	for _, vote := range votes {
+		if vote.comment != comment {
+			continue
+		}
+		if vote.Reason != "" {
+			return fmt.Sprintf("vote %+d by %v (ignored, %v)", vote.Score, vote.Login, vote.Reason)
+		}
+		return fmt.Sprintf("vote %+d by %v (counted)", vote.Score, vote.Login)
+	}
+	if login := getLogin(comment.User); login != "" {
+		return fmt.Sprintf("comment by %v (not counted, no vote)", login)
+	}
+	return "comment by an unknown user (not counted)"
+}
+
+// title returns the title of the pull request between brackets, marked when coming from a fork.
+func (d Decision) title() string {
+	title := fmt.Sprintf("(%v)", d.Title)
+	if d.Fork {
+		title += " (fork)"
+	}
+	return title
+}
+
+// String returns the decision as reported by Execute.
+func (d Decision) String() string {
+	if d.Action == ActionSkip {
+		return fmt.Sprintf("  - %v %v (%v) %v", d.Number, d.Action, d.Reason, d.title())
+	}
+	return fmt.Sprintf("  - %v %-5v %v %v", d.Number, d.Action, d.title(), d.Reason)
+}
+
+// Evaluate decides what to do with a pull request according to the repository's settings and the policies, at the given time.
+// Drafts, and pull requests gated by their labels, are decided before their votes are counted.
+// All policies and gates are evaluated and traced, the decision being given by the first one not passed.
+func Evaluate(ctx context.Context, client *GHClient, repository Repository, pullRequest *github.PullRequest, policies []Policy, now time.Time) Decision {
+	prInfo := pullRequestInfo(pullRequest, repository.Filter.WIP)
+	d := Decision{
+		PullRequestInfo: prInfo,
+		Repository:      repository,
+		Rules:           repository.Policy.ForPullRequest(prInfo),
+		Action:          ActionMerge,
+	}
+
+	branch := prInfo.Base
+	if prInfo.Fork {
+		branch += " (fork)"
+	}
+	d.trace("policy for %v: %v", branch, policyLine(d.Rules))
+	if prInfo.Draft {
+		d.trace("draft or work in progress: SKIP")
+		d.Action = ActionSkip
+		d.Reason = "draft"
+		d.Code = ReasonDraft
+		return d
+	}
+	if len(repository.SkipLabels) > 0 || len(repository.RequireLabels) > 0 {
+		labels, err := GetLabels(ctx, client, repository.Owner, repository.Name, prInfo.Number)
+		if err != nil {
+			d.trace("labels: %v", err)
+			d.Action, d.Reason, d.Code, d.Err = ActionNOP, "Failure getting labels", ReasonError, err
+			return d
+		}
+		d.trace("labels: %v", strings.Join(labels, ", "))
+		if action, reason := CheckLabels(labels, repository.SkipLabels, repository.RequireLabels); reason != "" {
+			d.trace("labels gate: %v (%v)", action, reason)
+			// the pull request is still needed to publish its status
+			if d.fetch(ctx, client) {
+				d.Action, d.Reason, d.Code = action, reason, ReasonLabels
+			}
+			return d
+		}
+	}
+
+	comments, err := scorePullRequest(ctx, client, repository.Owner, repository.Name, &d.PullRequestInfo, repository.Policy)
+	if err != nil {
+		d.trace("votes: %v", err)
+		d.Action, d.Reason, d.Code, d.Err = ActionNOP, "Failure counting votes", ReasonError, err
+		return d
+	}
+	prInfo = d.PullRequestInfo
+	required := d.Rules.Required
+	scoreReason := fmt.Sprintf("score %v of %v required", prInfo.Score, required)
+	d.trace("%v comments considered, %v with votes", prInfo.Comments, len(prInfo.Votes))
+	for _, comment := range comments {
+		d.trace("  %v", commentLine(comment, prInfo.Votes))
+	}
+
+	if !d.fetch(ctx, client) {
+		return d
+	}
+	for _, status := range d.statuses {
+		if status.Context == nil || status.State == nil {
+			continue
+		}
+		note := ""
+		if *status.Context == StatusContext {
+			note = " (Reviewer's own, ignored)"
+		} else if len(d.Rules.Contexts) > 0 && !hasContext(d.Rules.Contexts, *status.Context) {
+			note = " (not required, ignored)"
+		}
+		d.trace("status %v: %v%v", *status.Context, *status.State, note)
+	}
+
+	candidate := Candidate{
+		PullRequestInfo: prInfo,
+		PullRequest:     d.pullRequest,
+		Statuses:        d.statuses,
+		TestsState:      d.TestsState,
+		Rules:           d.Rules,
+	}
+	for _, policy := range policies {
+		passed, reason := policy.Check(candidate)
+		d.check(policy.Name(), reasonCode(policy), passed, reason)
+	}
+	wait := RemainingWait(prInfo, d.Rules, now)
+	d.check("minimum age", ReasonMinAge, wait <= 0, fmt.Sprintf("%v, waiting %v more", scoreReason, wait-wait%time.Second))
+	d.check("maintainer approval", ReasonMaintainer, ApprovedByMaintainer(prInfo.Votes, d.Rules.Maintainers), "Missing maintainer approval")
+	reason, err := CheckAuthor(ctx, client, d.Rules.Authors, prInfo.Author, prInfo.Association)
+	authorCode := ReasonAuthor
+	if err != nil {
+		reason = fmt.Sprintf("Failure checking author: %v", err)
+		authorCode = ReasonError
+		if d.Action == ActionMerge {
+			d.Err = err
+		}
+	} else if reason != "" {
+		reason = fmt.Sprintf("%v, %v", scoreReason, reason)
+	}
+	d.check("author", authorCode, reason == "", reason)
+	d.check("auto-merge", ReasonAutoMerge, !d.Rules.NoAutoMerge, scoreReason+", auto-merge disabled")
+
+	if hold, until := repository.Policy.Schedule.Hold(now); hold {
+		freeze := "freeze"
+		if !until.IsZero() {
+			freeze = fmt.Sprintf("freeze until %v", until.In(repository.Policy.Schedule.Location).Format("2006-01-02 15:04 MST"))
+		}
+		d.trace("schedule: %v", freeze)
+		if d.Action == ActionMerge {
+			d.Action = ActionHold
+			d.Reason = fmt.Sprintf("(%v) %v", freeze, scoreReason)
+			d.Code = ReasonSchedule
+		}
+	} else {
+		d.trace("schedule: ok")
+	}
+
+	if d.Action == ActionMerge {
+		d.Reason = scoreReason
+		d.Code = ReasonApproved
+	}
+	d.trace("decision: %v %v", d.Action, d.Reason)
+	return d
+}
@@ -0,0 +1,116 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"github.com/google/go-github/v32/github"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecisionString(t *testing.T) {
+	decision := Decision{
+		PullRequestInfo: PullRequestInfo{Number: 47, Title: "Changes CI badge location on README.md"},
+		Action:          ActionNOP,
+		Reason:          "score 1 of 3 required",
+	}
+	expected := "  - 47 NOP   (Changes CI badge location on README.md) score 1 of 3 required"
+	if decision.String() != expected {
+		t.Fatalf("Bad decision line %q (expected %q)", decision.String(), expected)
+	}
+
+	decision.Fork = true
+	decision.Action = ActionSkip
+	decision.Reason = "draft"
+	expected = "  - 47 SKIP (draft) (Changes CI badge location on README.md) (fork)"
+	if decision.String() != expected {
+		t.Fatalf("Bad decision line %q (expected %q)", decision.String(), expected)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	pullRequest, sha := newMockPullRequest(1, "Adds login", true), "abc123"
+	pullRequest.Head = &github.PullRequestBranch{SHA: &sha}
+	client := newMockGHClient([]*github.PullRequest{pullRequest}, nil)
+	client.Tickets.(*mockTicketsService).labels = []string{"do-not-merge"}
+	server := newMockStatusServer(client)
+	defer server.Close()
+	repository := Repository{
+		Name:       "repo",
+		Owner:      "user",
+		SkipLabels: []string{"do-not-merge"},
+	}
+
+	draft, yes := newMockPullRequest(1, "Adds login", true), true
+	draft.Draft = &yes
+	decision := Evaluate(context.Background(), client, repository, draft, DefaultPolicies(), time.Now())
+	if decision.Action != ActionSkip || decision.Reason != "draft" || decision.Code != ReasonDraft {
+		t.Fatalf("Draft pull request decided %v %v", decision.Action, decision.Reason)
+	}
+
+	decision = Evaluate(context.Background(), client, repository, pullRequest, DefaultPolicies(), time.Now())
+	if decision.Action != ActionNOP || decision.Reason != "Blocked by label do-not-merge" || decision.Code != ReasonLabels {
+		t.Fatalf("Blocked pull request decided %v %v", decision.Action, decision.Reason)
+	}
+	if decision.pullRequest == nil {
+		t.Fatal("The status of blocked pull requests should be published too")
+	}
+
+	repository.RequireLabels = []string{"ready"}
+	client.Tickets.(*mockTicketsService).labels = []string{}
+	decision = Evaluate(context.Background(), client, repository, pullRequest, DefaultPolicies(), time.Now())
+	if decision.Action != ActionSkip || decision.Reason != "Missing required label ready" || decision.Code != ReasonLabels {
+		t.Fatalf("Pull request without the required labels decided %v %v", decision.Action, decision.Reason)
+	}
+	if calls := client.Tickets.(*mockTicketsService).commentCalls; calls != 0 {
+		t.Fatalf("Votes of pull requests gated by labels shouldn't be counted, comments listed %v times", calls)
+	}
+	if len(decision.Trace) == 0 {
+		t.Fatal("Evaluate should trace every step")
+	}
+}
+
+func TestEvaluateTraceComments(t *testing.T) {
+	pullRequest, sha, author := newMockPullRequest(1, "Adds login", true), "abc123", "carol"
+	pullRequest.Head = &github.PullRequestBranch{SHA: &sha}
+	pullRequest.User = &github.User{Login: &author}
+	comments := []*github.IssueComment{
+		newMockComment("alice", "+1"),
+		newMockComment("bob", "Looks good, but the tests are missing"),
+		newMockComment("carol", "+1 from me"),
+		{Body: github.String("+1")},
+	}
+	client := newMockGHClient([]*github.PullRequest{pullRequest}, [][]*github.IssueComment{comments})
+	server := newMockStatusServer(client)
+	defer server.Close()
+	repository := Repository{Name: "repo", Owner: "user", Policy: RepoPolicy{Rules: Rules{Required: 2, Allowed: []string{"alice", "bob", "carol"}}}}
+
+	decision := Evaluate(context.Background(), client, repository, pullRequest, DefaultPolicies(), time.Now())
+	trace := strings.Join(decision.Trace, "\n")
+	for _, expected := range []string{
+		"4 comments considered, 2 with votes",
+		"  vote +1 by alice (counted)",
+		"  comment by bob (not counted, no vote)",
+		"  vote +1 by carol (ignored, self-vote)",
+		"  comment by an unknown user (not counted)",
+		"auto-merge: ok",
+	} {
+		if !strings.Contains(trace, expected+"\n") {
+			t.Fatalf("Trace should contain %q:\n%v", expected, trace)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Explain prints the full decision trace for a single pull request, given as owner/repo#number, without merging it.
+// It returns a ConfigError when the pull request isn't given right, or its repository isn't configured.
+func Explain(ctx context.Context, target string) error {
+	owner, repo, number, err := ParseTarget(target)
+	if err == nil && number == 0 {
+		err = errors.New("Pull request number not given, expected owner/repo#number")
+	}
+	if err != nil {
+		return ConfigError{err}
+	}
+	repositories, aliases, schedule, client, err := loadConfiguration()
+	if err != nil {
+		return err
+	}
+	repoName, err := findRepository(repositories, owner, repo)
+	if err != nil {
+		return ConfigError{err}
+	}
+	repository, err := LoadRepository(repositories, repoName, aliases, schedule)
+	if err != nil {
+		return ConfigError{err}
+	}
+
+	fmt.Fprintf(Stdout, "%v/%v#%v\n", repository.Owner, repoName, number)
+	if !repository.Enabled {
+		fmt.Fprintf(Stdout, "  repository: disabled\n")
+		return nil
+	}
+	pullRequest, _, err := client.Changes.Get(ctx, repository.Owner, repoName, number)
+	if err != nil {
+		return failure(err, "Error getting pull request %v", target)
+	}
+	fmt.Fprintf(Stdout, "  title: %v\n", *pullRequest.Title)
+	fmt.Fprintf(Stdout, "  author: %v\n", getLogin(pullRequest.User))
+	if base := getBase(pullRequest); !repository.Filter.MatchesBranch(base) {
+		fmt.Fprintf(Stdout, "  base branch %v: not evaluated according to branches\n", base)
+		return nil
+	}
+	decision := Evaluate(ctx, client, repository, pullRequest, DefaultPolicies(), Now())
+	for _, step := range decision.Trace {
+		fmt.Fprintf(Stdout, "  %v\n", step)
+	}
+	if decision.Err != nil {
+		return failure(decision.Err, "Error evaluating %v", target)
+	}
+	return nil
+}
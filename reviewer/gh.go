@@ -15,13 +15,18 @@
 package reviewer
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"os"
+	"regexp"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
-	"github.com/google/go-github/github"
+	"github.com/google/go-github/v32/github"
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 )
@@ -29,15 +34,35 @@ import (
 // GetString contains the function used to lookup environment variables.
 var GetString = viper.GetString
 
+// GetInt contains the function used to lookup integer settings.
+var GetInt = viper.GetInt
+
 // ChangesServicer is an interface for listing changes.
 type ChangesServicer interface {
-	List(string, string, *github.PullRequestListOptions) ([]github.PullRequest, *github.Response, error)
-	Get(string, string, int) (*github.PullRequest, *github.Response, error)
+	List(context.Context, string, string, *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error)
+	Get(context.Context, string, string, int) (*github.PullRequest, *github.Response, error)
+	ListCommits(context.Context, string, string, int, *github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error)
 }
 
 // TicketsServicer is an interface for listing changes.
 type TicketsServicer interface {
-	ListComments(string, string, int, *github.IssueListCommentsOptions) ([]github.IssueComment, *github.Response, error)
+	ListComments(context.Context, string, string, int, *github.IssueListCommentsOptions) ([]*github.IssueComment, *github.Response, error)
+	CreateComment(context.Context, string, string, int, *github.IssueComment) (*github.IssueComment, *github.Response, error)
+	EditComment(context.Context, string, string, int64, *github.IssueComment) (*github.IssueComment, *github.Response, error)
+	ListLabelsByIssue(context.Context, string, string, int, *github.ListOptions) ([]*github.Label, *github.Response, error)
+	AddLabelsToIssue(context.Context, string, string, int, []string) ([]*github.Label, *github.Response, error)
+	RemoveLabelForIssue(context.Context, string, string, int, string) (*github.Response, error)
+}
+
+// TeamsServicer is an interface for checking team memberships.
+type TeamsServicer interface {
+	ListTeams(context.Context, string, *github.ListOptions) ([]*github.Team, *github.Response, error)
+	GetTeamMembershipBySlug(context.Context, string, string, string) (*github.Membership, *github.Response, error)
+}
+
+// UsersServicer is an interface for getting users.
+type UsersServicer interface {
+	Get(context.Context, string) (*github.User, *github.Response, error)
 }
 
 // GHClient is the wrapper around github.Client.
@@ -45,6 +70,10 @@ type GHClient struct {
 	client  *github.Client
 	Changes ChangesServicer
 	Tickets TicketsServicer
+	Teams   TeamsServicer
+	Users   UsersServicer
+
+	login atomic.Value // login of the authenticated user, once known
 }
 
 // NewGHClient is the constructor for GHClient.
@@ -54,21 +83,67 @@ func NewGHClient(httpClient *http.Client) *GHClient {
 	}
 	client.Changes = client.client.PullRequests
 	client.Tickets = client.client.Issues
+	client.Teams = client.client.Teams
+	client.Users = client.client.Users
 	return client
 }
 
+// Login returns the login of the authenticated user, asking GitHub only the first time.
+func (client *GHClient) Login(ctx context.Context) (string, error) {
+	if login, ok := client.login.Load().(string); ok {
+		return login, nil
+	}
+	user, _, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	login := getLogin(user)
+	client.login.Store(login)
+	return login, nil
+}
+
+// Reasons for a vote not being counted.
+const (
+	VoteNotAllowed = "not in allowed"
+	VoteSelf       = "self-vote"
+	VoteCoAuthor   = "commit author"
+	VoteAlias      = "alias of an author"
+	VoteStale      = "stale"
+)
+
+// Vote is a +1 or -1 found in a pull request comment.
+type Vote struct {
+	Login  string
+	Score  int
+	Reason string    // why the vote was ignored, empty if it was counted
+	At     time.Time // when the vote was cast, or its comment last edited
+
+	comment *github.IssueComment // where the vote was cast
+}
+
 // PullRequestInfo contains the id, title, and CR score of a pull request.
 type PullRequestInfo struct {
-	Number int // id of the pull request
-	Title  string
-	Score  int
+	Number      int // id of the pull request
+	Title       string
+	Base        string // base branch
+	Score       int
+	Votes       []Vote
+	Comments    int  // comments considered for votes
+	Draft       bool // draft or work in progress, not evaluated
+	Fork        bool // coming from another repository
+	Author      string
+	Association string    // author association, e.g. FIRST_TIME_CONTRIBUTOR
+	CreatedAt   time.Time // when the pull request was opened
+	ApprovedAt  time.Time // since when the score reaches the required one, zero if it doesn't
+	SummaryID   int64     // id of Reviewer's summary comment, posted by the authenticated user, 0 if there's none
+	SummaryBody string
 }
 
 // GetClient returns a github.Client authenticated.
 func GetClient() (*GHClient, error) {
 	token := GetString("authorization.token")
 	if token == "" {
-		return nil, errors.New("An error occurred getting REVIEWER_TOKEN environment variable\n")
+		return nil, AuthError{errors.New("An error occurred getting REVIEWER_TOKEN environment variable")}
 	}
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
@@ -90,42 +165,306 @@ func getCommentSuccessScore(comment string) int {
 	return score
 }
 
-// GetPullRequestInfos returns the list of pull requests and the CR success score based on comments
-func GetPullRequestInfos(client *GHClient, owner string, repo string, allowedUserLogins []string) ([]PullRequestInfo, error) {
-	//TODO: https://github.com/gophergala2016/reviewer/issues/23
+// getLogin returns the user's login, or an empty string if unknown.
+func getLogin(user *github.User) string {
+	if user == nil || user.Login == nil {
+		return ""
+	}
+	return *user.Login
+}
+
+// countVotes returns the votes found in the comments, in the order they were cast, and the resulting score.
+// Only the latest vote of each allowed reviewer is counted, and never the ones of the excluded logins, given with the reason.
+// Edited comments are taken as cast when last edited.
+func countVotes(comments []*github.IssueComment, excluded map[string]string, allowedUserLogins []string) ([]Vote, int) {
+	users := make(map[string]bool)
+	for _, allowed := range allowedUserLogins {
+		users[allowed] = true
+	}
+
+	votes := []Vote{}
+	for _, comment := range comments {
+		if comment.Body == nil || getLogin(comment.User) == "" {
+			continue
+		}
+		score := getCommentSuccessScore(*comment.Body)
+		if score == 0 {
+			continue
+		}
+		vote := Vote{Login: getLogin(comment.User), Score: score, comment: comment}
+		if comment.UpdatedAt != nil {
+			vote.At = *comment.UpdatedAt
+		} else if comment.CreatedAt != nil {
+			vote.At = *comment.CreatedAt
+		}
+		votes = append(votes, vote)
+	}
+	sort.SliceStable(votes, func(i, j int) bool {
+		return votes[i].At.Before(votes[j].At)
+	})
+
+	latest := make(map[string]int)
+	for n, vote := range votes {
+		if reason, exists := excluded[strings.ToLower(vote.Login)]; exists {
+			votes[n].Reason = reason
+		} else if !users[vote.Login] {
+			votes[n].Reason = VoteNotAllowed
+		} else {
+			if previous, exists := latest[vote.Login]; exists {
+				votes[previous].Reason = VoteStale
+			}
+			latest[vote.Login] = n
+		}
+	}
 
-	pullRequests, _, err := client.Changes.List(owner, repo, nil)
+	total := 0
+	for _, vote := range votes {
+		if vote.Reason == "" {
+			total += vote.Score
+		}
+	}
+	return votes, total
+}
+
+// approvedSince returns since when the score of the votes reaches the required one, or zero time if it doesn't.
+func approvedSince(votes []Vote, required int) time.Time {
+	current := make(map[string]int)
+	total := 0
+	var since time.Time
+	for _, vote := range votes {
+		if vote.Reason != "" && vote.Reason != VoteStale {
+			continue
+		}
+		total += vote.Score - current[vote.Login]
+		current[vote.Login] = vote.Score
+		if total < required {
+			since = time.Time{}
+		} else if since.IsZero() {
+			since = vote.At
+		}
+	}
+	return since
+}
+
+// Now contains the function used to get the current time.
+var Now = time.Now
+
+// RemainingWait returns how long the pull request must still wait before being merged according to the policy's minimum ages.
+func RemainingWait(prInfo PullRequestInfo, policy Rules, now time.Time) time.Duration {
+	var wait time.Duration
+	if policy.MinAge > 0 && !prInfo.CreatedAt.IsZero() {
+		wait = prInfo.CreatedAt.Add(policy.MinAge).Sub(now)
+	}
+	if policy.MinApprovalAge > 0 && !prInfo.ApprovedAt.IsZero() {
+		if approvalWait := prInfo.ApprovedAt.Add(policy.MinApprovalAge).Sub(now); approvalWait > wait {
+			wait = approvalWait
+		}
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// IgnoredVotes returns the votes not counted with their reasons, e.g. " (ignored: bob self-vote)", or an empty string if all were.
+func IgnoredVotes(votes []Vote) string {
+	ignored := []string{}
+	for _, vote := range votes {
+		if vote.Reason != "" {
+			ignored = append(ignored, fmt.Sprintf("%v %v", vote.Login, vote.Reason))
+		}
+	}
+	if len(ignored) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (ignored: %v)", strings.Join(ignored, ", "))
+}
+
+// DefaultWIPPattern is the default regular expression matching the titles of work in progress pull requests.
+const DefaultWIPPattern = `^\s*(\[?WIP\]?|Draft:)`
+
+// IsDraft returns true if the pull request is a draft, or its title matches the work in progress pattern.
+func IsDraft(pullRequest *github.PullRequest, wip *regexp.Regexp) bool {
+	if pullRequest.Draft != nil && *pullRequest.Draft {
+		return true
+	}
+	return wip != nil && pullRequest.Title != nil && wip.MatchString(*pullRequest.Title)
+}
+
+// getBase returns the base branch of the pull request, or an empty string if unknown.
+func getBase(pullRequest *github.PullRequest) string {
+	if pullRequest.Base == nil || pullRequest.Base.Ref == nil {
+		return ""
+	}
+	return *pullRequest.Base.Ref
+}
+
+// IsFork returns true if the pull request comes from a repository other than its base one, including deleted ones.
+func IsFork(pullRequest *github.PullRequest) bool {
+	if pullRequest.Head == nil || pullRequest.Base == nil || pullRequest.Base.Repo == nil {
+		return false
+	}
+	head := pullRequest.Head.Repo
+	base := pullRequest.Base.Repo
+	return head == nil || head.FullName == nil || base.FullName == nil || *head.FullName != *base.FullName
+}
+
+// GetPullRequestInfos returns the list of pull requests and the CR success score based on comments.
+// Pull requests into branches not matching the filter are left out.
+func GetPullRequestInfos(ctx context.Context, client *GHClient, owner string, repo string, policy RepoPolicy, filter Filter) ([]PullRequestInfo, error) {
+	pullRequests, err := listPullRequests(ctx, client, owner, repo, filter)
 	if err != nil {
 		return nil, err
 	}
-	pris := make([]PullRequestInfo, len(pullRequests))
-	for n, pullRequest := range pullRequests {
-		pris[n].Number = *pullRequest.Number
-		pris[n].Title = *pullRequest.Title
-		comments, _, err := client.Tickets.ListComments(owner, repo, *pullRequest.Number, nil)
+	pris := make([]PullRequestInfo, 0, len(pullRequests))
+	for _, pullRequest := range pullRequests {
+		prInfo, err := GetPullRequestInfo(ctx, client, owner, repo, pullRequest, policy, filter.WIP)
 		if err != nil {
 			return nil, err
 		}
+		pris = append(pris, prInfo)
+	}
+	return pris, nil
+}
+
+// listPullRequests returns the open pull requests of the repository into branches matching the filter.
+func listPullRequests(ctx context.Context, client *GHClient, owner string, repo string, filter Filter) ([]*github.PullRequest, error) {
+	//TODO: https://github.com/gophergala2016/reviewer/issues/23
 
-		users := make(map[string]bool)
-		for _, allowed := range allowedUserLogins {
-			users[allowed] = true
+	pullRequests, _, err := client.Changes.List(ctx, owner, repo, filter.listOptions())
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]*github.PullRequest, 0, len(pullRequests))
+	for _, pullRequest := range pullRequests {
+		if filter.MatchesBranch(getBase(pullRequest)) {
+			matching = append(matching, pullRequest)
 		}
+	}
+	return matching, nil
+}
 
-		for _, comment := range comments {
-			if comment.Body == nil || *comment.User.Login == *pullRequest.User.Login {
-				continue
+// getSelectedPullRequests returns the pull requests with the given numbers.
+// Pull requests not open, or into branches not matching the filter, are left out.
+func getSelectedPullRequests(ctx context.Context, client *GHClient, owner string, repo string, numbers []int, filter Filter) ([]*github.PullRequest, error) {
+	pullRequests := make([]*github.PullRequest, 0, len(numbers))
+	for _, number := range numbers {
+		pullRequest, _, err := client.Changes.Get(ctx, owner, repo, number)
+		if err != nil {
+			return nil, err
+		}
+		if pullRequest.GetState() != "open" {
+			Log.Infof("%v/%v#%v Skipping, the pull request is %v", owner, repo, number, pullRequest.GetState())
+			continue
+		}
+		if filter.MatchesBranch(getBase(pullRequest)) {
+			pullRequests = append(pullRequests, pullRequest)
+		}
+	}
+	return pullRequests, nil
+}
+
+// GetPullRequestInfo returns the information and the CR success score based on comments of a pull request.
+// Votes are counted according to the allowed reviewers of the policy for the pull request.
+// Drafts, and pull requests with titles matching wip, are flagged and not scored.
+func GetPullRequestInfo(ctx context.Context, client *GHClient, owner string, repo string, pullRequest *github.PullRequest, policy RepoPolicy, wip *regexp.Regexp) (PullRequestInfo, error) {
+	prInfo := pullRequestInfo(pullRequest, wip)
+	if prInfo.Draft {
+		return prInfo, nil
+	}
+	_, err := scorePullRequest(ctx, client, owner, repo, &prInfo, policy)
+	return prInfo, err
+}
+
+// pullRequestInfo returns the information of a pull request, without its score.
+// Drafts, and pull requests with titles matching wip, are flagged.
+func pullRequestInfo(pullRequest *github.PullRequest, wip *regexp.Regexp) PullRequestInfo {
+	prInfo := PullRequestInfo{
+		Number: *pullRequest.Number,
+		Title:  *pullRequest.Title,
+		Base:   getBase(pullRequest),
+		Fork:   IsFork(pullRequest),
+		Author: getLogin(pullRequest.User),
+		Draft:  IsDraft(pullRequest, wip),
+	}
+	if pullRequest.AuthorAssociation != nil {
+		prInfo.Association = *pullRequest.AuthorAssociation
+	}
+	if pullRequest.CreatedAt != nil {
+		prInfo.CreatedAt = *pullRequest.CreatedAt
+	}
+	return prInfo
+}
+
+// scorePullRequest counts the votes in the comments of the pull request, finding Reviewer's summary among them.
+// Votes are counted according to the allowed reviewers of the policy for the pull request.
+// It returns the comments considered for votes.
+func scorePullRequest(ctx context.Context, client *GHClient, owner string, repo string, prInfo *PullRequestInfo, policy RepoPolicy) ([]*github.IssueComment, error) {
+	comments, err := listComments(ctx, client, owner, repo, prInfo.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	votable := make([]*github.IssueComment, 0, len(comments))
+	for _, comment := range comments {
+		if comment.Body != nil && comment.ID != nil && strings.Contains(*comment.Body, SummaryMarker) {
+			login, err := client.Login(ctx)
+			if err != nil {
+				return nil, err
 			}
-			score := getCommentSuccessScore(*comment.Body)
-			if score != 0 {
-				if _, exists := users[*comment.User.Login]; exists {
-					pris[n].Score += score
-					delete(users, *comment.User.Login)
-				}
+			// others quoting the summary still vote
+			if strings.EqualFold(getLogin(comment.User), login) {
+				prInfo.SummaryID = *comment.ID
+				prInfo.SummaryBody = *comment.Body
+				continue
 			}
 		}
+		votable = append(votable, comment)
+	}
+	prInfo.Comments = len(votable)
+	commits, err := listCommits(ctx, client, owner, repo, prInfo.Number)
+	if err != nil {
+		return nil, err
+	}
+	excluded := excludedVoters(prInfo.Author, commits, policy.Aliases)
+	effective := policy.ForPullRequest(*prInfo)
+	prInfo.Votes, prInfo.Score = countVotes(votable, excluded, effective.Allowed)
+	prInfo.ApprovedAt = approvedSince(prInfo.Votes, effective.Required)
+	return votable, nil
+}
+
+// listComments returns all the comments of the pull request, going through every page.
+func listComments(ctx context.Context, client *GHClient, owner string, repo string, number int) ([]*github.IssueComment, error) {
+	options := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var comments []*github.IssueComment
+	for {
+		page, response, err := client.Tickets.ListComments(ctx, owner, repo, number, options)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, page...)
+		if response == nil || response.NextPage == 0 {
+			return comments, nil
+		}
+		options.Page = response.NextPage
+	}
+}
+
+// listCommits returns all the commits of the pull request, going through every page.
+func listCommits(ctx context.Context, client *GHClient, owner string, repo string, number int) ([]*github.RepositoryCommit, error) {
+	options := &github.ListOptions{PerPage: 100}
+	var commits []*github.RepositoryCommit
+	for {
+		page, response, err := client.Changes.ListCommits(ctx, owner, repo, number, options)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, page...)
+		if response == nil || response.NextPage == 0 {
+			return commits, nil
+		}
+		options.Page = response.NextPage
 	}
-	return pris, nil
 }
 
 // IsMergeable returns true if the PullRequest is mergeable.
@@ -134,93 +473,346 @@ func IsMergeable(pullRequest *github.PullRequest) bool {
 	return (pullRequest.Mergeable != nil) && *pullRequest.Mergeable
 }
 
-// PassedTests checks if the PR statuses are ok.
-func PassedTests(client *GHClient, pullRequest *github.PullRequest, owner string, repo string) (bool, error) {
+// StatusContext is the context of the commit status published by Reviewer.
+const StatusContext = "reviewer/score"
+
+// maxStatusDescription is the maximum length of a commit status description allowed by GitHub.
+const maxStatusDescription = 140
+
+// GetStatuses returns the statuses of the PR's head commit.
+func GetStatuses(ctx context.Context, client *GHClient, pullRequest *github.PullRequest, owner string, repo string) ([]*github.RepoStatus, error) {
 	head := *pullRequest.Head.SHA
-	combinedStatus, _, err := client.client.Repositories.GetCombinedStatus(owner, repo, head, nil)
+	combinedStatus, _, err := client.client.Repositories.GetCombinedStatus(ctx, owner, repo, head, nil)
+
+	if err != nil {
+		return nil, err
+	}
+	return combinedStatus.Statuses, nil
+}
+
+// CombineStatuses returns the combined state of the statuses, leaving Reviewer's own status out.
+// As GitHub does, it's pending when there are no statuses at all.
+// When contexts are given, only those are considered, and any of them missing is pending.
+func CombineStatuses(statuses []*github.RepoStatus, contexts []string) string {
+	pending := false
+	succeeded := false
+	seen := make(map[string]bool)
+	for _, status := range statuses {
+		if status.State == nil || status.Context == nil || *status.Context == StatusContext {
+			continue
+		}
+		if len(contexts) > 0 && !hasContext(contexts, *status.Context) {
+			continue
+		}
+		seen[*status.Context] = true
+		switch *status.State {
+		case "error", "failure":
+			return "failure"
+		case "success":
+			succeeded = true
+		default:
+			pending = true
+		}
+	}
+	if pending || !succeeded || len(seen) < len(contexts) {
+		return "pending"
+	}
+	return "success"
+}
 
+// hasContext returns true if the context is in the list.
+func hasContext(contexts []string, context string) bool {
+	for _, c := range contexts {
+		if c == context {
+			return true
+		}
+	}
+	return false
+}
+
+// PassedTests checks if the PR statuses are ok.
+func PassedTests(ctx context.Context, client *GHClient, pullRequest *github.PullRequest, owner string, repo string) (bool, error) {
+	statuses, err := GetStatuses(ctx, client, pullRequest, owner, repo)
 	if err != nil {
 		return false, err
 	}
-	return (*combinedStatus.State == "success"), nil
+	return CombineStatuses(statuses, nil) == "success", nil
 }
 
-// Merge does the merge.
-func Merge(client *GHClient, owner string, repo string, number int) (*github.PullRequestMergeResult, error) {
-	result, _, err := client.client.PullRequests.Merge(owner, repo, number, "Merged automatically by Reviewer")
-	return result, err
+// DecisionStatus returns the state and description of Reviewer's commit status for a decision:
+// success when the pull request would be merged, failure when its tests failed or it isn't mergeable,
+// error when it couldn't be evaluated, and pending otherwise.
+func DecisionStatus(d Decision) (string, string) {
+	description := fmt.Sprintf("%v: %v", d.Action, d.Reason)
+	if runes := []rune(description); len(runes) > maxStatusDescription {
+		description = string(runes[:maxStatusDescription-3]) + "..."
+	}
+	switch {
+	case d.Action == ActionMerge:
+		return "success", description
+	case d.Code == ReasonError:
+		return "error", description
+	case d.Mergeable != nil && !*d.Mergeable, d.TestsState == "failure":
+		return "failure", description
+	}
+	return "pending", description
 }
 
-// Execute checks if the PR defers to be merged.
-func Execute(DryRun bool) bool {
-	if DryRun {
-		fmt.Printf("Working in dry-run mode...\n")
+// PublishStatus sets Reviewer's commit status on the PR's head commit, unless it already has the same one.
+func PublishStatus(ctx context.Context, client *GHClient, pullRequest *github.PullRequest, owner string, repo string, statuses []*github.RepoStatus, state string, description string) error {
+	for _, status := range statuses {
+		if status.Context == nil || *status.Context != StatusContext {
+			continue
+		}
+		if status.State != nil && *status.State == state && status.Description != nil && *status.Description == description {
+			return nil
+		}
+		break
 	}
+	context := StatusContext
+	_, _, err := client.client.Repositories.CreateStatus(ctx, owner, repo, *pullRequest.Head.SHA, &github.RepoStatus{
+		State:       &state,
+		Description: &description,
+		Context:     &context,
+	})
+	return err
+}
+
+// Merge does the merge, using the merge method given: merge, squash or rebase.
+func Merge(ctx context.Context, client *GHClient, owner string, repo string, number int, mergeMethod string) (*github.PullRequestMergeResult, error) {
+	options := &github.PullRequestOptions{MergeMethod: mergeMethod}
+	result, _, err := client.client.PullRequests.Merge(ctx, owner, repo, number, "Merged automatically by Reviewer", options)
+	return result, err
+}
+
+// Options contains the settings given through the command line.
+type Options struct {
+	DryRun        bool            // Won't merge if enabled.
+	PublishStatus bool            // Publishes the commit status even in dry-run mode.
+	Targets       []Target        // Repositories or pull requests to process, all of them when empty.
+	Output        string          // Format of the report, text when empty.
+	ReportFile    string          // File the report is written to, stdout when empty.
+	FailOnNOP     bool            // Returns a NOPError if some pull requests weren't merged.
+	Stop          <-chan struct{} // Stops processing between pull requests when closed.
+	Timeout       time.Duration   // Deadline for the whole run, none when 0.
+	Policies      []Policy        // Checked in order on every pull request, the built-in ones when nil.
+}
+
+// loadSettings checks the configuration file and returns the repositories' configuration, and the global aliases and schedule.
+func loadSettings() (*Config, [][]string, *Schedule, error) {
 	err := CheckFile()
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, nil, err
 	}
 	err = CheckRepositories()
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, nil, err
 	}
 	repositories := NewConfig(viper.Sub("repositories"))
+	var aliases [][]string
+	if IsSet("aliases") {
+		aliases = LoadAliases(NewConfig(viper.Sub("aliases")))
+	}
+	schedule, err := LoadSchedule(NewConfig(viper.GetViper()), "schedule")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return repositories, aliases, schedule, nil
+}
+
+// loadConfiguration checks the configuration file and returns the repositories' configuration,
+// the global aliases and schedule, and the GitHub client.
+func loadConfiguration() (*Config, [][]string, *Schedule, *GHClient, error) {
+	repositories, aliases, schedule, err := loadSettings()
+	if err != nil {
+		return nil, nil, nil, nil, ConfigError{err}
+	}
 	client, err := GetClient()
 	if err != nil {
-		log.Fatalf("Error creating GitHub client %v", err)
+		return nil, nil, nil, nil, err
 	}
+	return repositories, aliases, schedule, client, nil
+}
+
+// publish publishes the commit status, the summary comment and the labels of the evaluated pull request, as configured.
+// It returns the failures found.
+func publish(ctx context.Context, client *GHClient, decision Decision, settings Settings) []error {
+	if decision.pullRequest == nil {
+		return nil
+	}
+	var failures []error
+	repository := decision.Repository
+	prInfo := decision.PullRequestInfo
+	required := decision.Rules.Required
+	where := fmt.Sprintf("%v/%v#%v", repository.Owner, repository.Name, prInfo.Number)
+	if !settings.DryRun || settings.PublishStatus {
+		state, description := DecisionStatus(decision)
+		err := PublishStatus(ctx, client, decision.pullRequest, repository.Owner, repository.Name, decision.statuses, state, description)
+		if err != nil {
+			failures = append(failures, failure(err, "%v Failure publishing status", where))
+		}
+	}
+	// the votes of pull requests gated by their labels aren't counted
+	if settings.DryRun || decision.Code == ReasonLabels {
+		return failures
+	}
+	if repository.Comment {
+		body := SummaryComment(prInfo, required, decision.Mergeable, decision.TestsState)
+		err := UpdateSummary(ctx, client, repository.Owner, repository.Name, prInfo, body)
+		if err != nil {
+			failures = append(failures, failure(err, "%v Failure updating summary comment", where))
+		}
+	}
+	if len(repository.Labels) > 0 {
+		err := SyncLabels(ctx, client, repository.Owner, repository.Name, prInfo.Number, repository.Labels, LabelStates(prInfo, required, decision.TestsState))
+		if err != nil {
+			failures = append(failures, failure(err, "%v Failure updating labels", where))
+		}
+	}
+	return failures
+}
+
+// Execute checks if the PR defers to be merged.
+// It returns a ConfigError or an AuthError when nothing could be processed, or some repositories' settings are wrong,
+// a RunError when some repositories or pull requests couldn't be processed, and a NOPError when asked to fail on NOP
+// and some pull requests weren't merged.
+// Cancelling the context aborts the GitHub calls in progress, while closing options.Stop lets them finish.
+func Execute(ctx context.Context, options Options) error {
+	if options.DryRun {
+		Log.Infof("Working in dry-run mode")
+	}
+	repositories, aliases, schedule, client, err := loadConfiguration()
+	if err != nil {
+		return err
+	}
+	return run(ctx, client, repositories, aliases, schedule, options)
+}
+
+// stopping returns true if the stop channel is closed.
+func stopping(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// run evaluates the pull requests of the configured repositories with an Engine, merging them when they defer to,
+// and reports them in the output format.
+// It returns early, between pull requests, when options.Stop is closed, or when GitHub rejects the credentials,
+// and as soon as the context is done, or options.Timeout elapses.
+func run(ctx context.Context, client *GHClient, repositories *Config, aliases [][]string, schedule *Schedule, options Options) (result error) {
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+	w := Stdout
+	if options.ReportFile != "" {
+		file, err := os.Create(options.ReportFile)
+		if err != nil {
+			return fmt.Errorf("Error creating report file: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	reporter, err := NewReporter(options.Output, w)
+	if err != nil {
+		return ConfigError{err}
+	}
+	defer func() {
+		if err := reporter.Close(); err != nil && result == nil {
+			result = fmt.Errorf("Error writing report: %v", err)
+		}
+	}()
+	engine := NewEngine(client, Settings{
+		MaxMerges:     GetInt("max_merges_per_run"),
+		DryRun:        options.DryRun,
+		PublishStatus: options.PublishStatus,
+		Policies:      options.Policies,
+		Stop:          options.Stop,
+	})
+	nops := 0
+	var failures []error
 
 	//TODO: https://github.com/gophergala2016/reviewer/issues/38
-	for _, repoName := range repositories.AllKeys() {
-		username := repositories.GetString(repoName + ".username")
-		status := repositories.GetBool(repoName + ".status")
-		required := repositories.GetInt(repoName + ".required")
-		allowed := repositories.GetStringSlice(repoName + ".allowed")
-
-		if !status {
-			fmt.Printf("- %v/%v Discarded (repo disabled)\n", username, repoName)
+	for _, repoName := range RepositoryNames(repositories) {
+		if stopping(options.Stop) {
+			return runError(failures, nops, options)
+		}
+		selected, numbers := selectTargets(options.Targets, repositories.GetString(repoName+".username"), repoName)
+		if !selected {
 			continue
 		}
-		prInfos, err := GetPullRequestInfos(client, username, repoName, allowed)
+		repository, err := LoadRepository(repositories, repoName, aliases, schedule)
 		if err != nil {
-			fmt.Printf("Error getting pull request info of repo %v/%v", username, repoName)
+			failures = append(failures, ConfigError{fmt.Errorf("%v/%v: %v", repository.Owner, repoName, err)})
+			reporter.Repository(repository.Owner, repoName, err.Error())
 			continue
 		}
-		fmt.Printf("+ %v/%v\n", username, repoName)
-		for _, prInfo := range prInfos {
-			pullRequest, _, err := client.Changes.Get(username, repoName, prInfo.Number)
-			if err != nil {
-				fmt.Printf("  - %v NOP   (%v) Failure getting pull request\n", prInfo.Number, prInfo.Title)
-				continue
+		if !repository.Enabled {
+			reporter.Repository(repository.Owner, repoName, DiscardedDisabled)
+			continue
+		}
+		decisions, err := engine.Evaluate(ctx, repository, numbers...)
+		if ctx.Err() != nil {
+			return runError(append(failures, ctx.Err()), nops, options)
+		}
+		if err != nil {
+			reporter.Repository(repository.Owner, repoName, fmt.Sprintf("error getting pull request info: %v", err))
+			err = failure(err, "%v/%v Failure getting pull request info", repository.Owner, repoName)
+			if IsAuthError(err) {
+				return err
 			}
-			if !IsMergeable(pullRequest) {
-				fmt.Printf("  - %v NOP   (%v) Not mergeable\n", prInfo.Number, prInfo.Title)
-				continue
+			failures = append(failures, err)
+			continue
+		}
+		reporter.Repository(repository.Owner, repoName, "")
+		for _, decision := range decisions {
+			if stopping(options.Stop) {
+				return runError(failures, nops, options)
 			}
-			var passedTests bool
-			passedTests, err = PassedTests(client, pullRequest, username, repoName)
-			if err != nil {
-				fmt.Printf("  - %v NOP   (%v) %s\n", prInfo.Number, prInfo.Title, err)
-				continue
+			result, err := engine.Apply(ctx, decision)
+			if ctx.Err() != nil {
+				return runError(append(failures, ctx.Err()), nops, options)
 			}
-			if !passedTests {
-				fmt.Printf("  - %v NOP   (%v) Tests not passed\n", prInfo.Number, prInfo.Title)
-				continue
+			reporter.PullRequest(result)
+			if result.Action == ActionNOP {
+				nops++
 			}
-			if prInfo.Score < required {
-				fmt.Printf("  - %v NOP   (%v) score %v of %v required\n", prInfo.Number, prInfo.Title, prInfo.Score, required)
-				continue
+			if IsAuthError(err) {
+				return err
 			}
-			if !DryRun {
-				_, err := Merge(client, username, repoName, prInfo.Number)
-				if err != nil {
-					fmt.Printf("  + %v -merge- (%v)  Merge failed: %v\n", prInfo.Number, prInfo.Title, err)
-				}
-				fmt.Printf("  + %v MERGE (%v) score %v of %v required\n", prInfo.Number, prInfo.Title, prInfo.Score, required)
-			} else {
-				fmt.Printf("  - %v (merge)  (%v) score %v of %v required\n", prInfo.Number, prInfo.Title, prInfo.Score, required)
+			if runErr, ok := err.(RunError); ok {
+				failures = append(failures, runErr.Errors...)
+			} else if err != nil {
+				failures = append(failures, err)
 			}
 		}
 	}
-	return true
+	for _, target := range options.Targets {
+		if _, err := findRepository(repositories, target.Owner, target.Repo); err != nil {
+			reporter.Repository(target.Owner, target.Repo, "not configured")
+			failures = append(failures, ConfigError{err})
+		}
+	}
+	return runError(failures, nops, options)
+}
+
+// runError returns the error of a run with the failures and the pull requests decided NOP.
+// The failures are returned as a ConfigError if some repository's settings were wrong, so they aren't taken as transient.
+func runError(failures []error, nops int, options Options) error {
+	for _, err := range failures {
+		if _, ok := err.(ConfigError); ok {
+			return ConfigError{RunError{failures}}
+		}
+	}
+	if len(failures) > 0 {
+		return RunError{failures}
+	}
+	if options.FailOnNOP && nops > 0 {
+		return NOPError{nops}
+	}
+	return nil
 }
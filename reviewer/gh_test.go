@@ -15,11 +15,19 @@
 package reviewer
 
 import (
-	reviewer "."
-
-	"github.com/google/go-github/github"
+	"bytes"
+	"context"
+	"github.com/google/go-github/v32/github"
+	"github.com/spf13/viper"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 // token contains the GH token.
@@ -27,48 +35,161 @@ var token = "GITHUB_USERS_TOKEN"
 
 // mockChangesService is a mock for github.PullRequestsService.
 type mockChangesService struct {
-	listPullRequests []github.PullRequest
+	listPullRequests []*github.PullRequest
+	listCommits      [][]*github.RepositoryCommit
+	listPerPage      int // pull requests listed per page, all of them in one when 0
 }
 
 // newMockChangesService creates a new ChangesService implementation.
-func newMockChangesService(listPR []github.PullRequest) *mockChangesService {
+func newMockChangesService(listPR []*github.PullRequest) *mockChangesService {
 	return &mockChangesService{
 		listPullRequests: listPR,
 	}
 }
 
 // mockChangesService's List implementation.
-func (m *mockChangesService) List(owner string, repo string, opt *github.PullRequestListOptions) ([]github.PullRequest, *github.Response, error) {
-	return m.listPullRequests, nil, nil
+func (m *mockChangesService) List(ctx context.Context, owner string, repo string, opt *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if m.listPerPage == 0 {
+		return m.listPullRequests, nil, nil
+	}
+	page := 1
+	if opt != nil && opt.Page > 0 {
+		page = opt.Page
+	}
+	start := (page - 1) * m.listPerPage
+	if start >= len(m.listPullRequests) {
+		return nil, &github.Response{}, nil
+	}
+	response := &github.Response{}
+	end := start + m.listPerPage
+	if end < len(m.listPullRequests) {
+		response.NextPage = page + 1
+	} else {
+		end = len(m.listPullRequests)
+	}
+	return m.listPullRequests[start:end], response, nil
 }
 
-// mockChangesService's List implementation.
-func (m *mockChangesService) Get(owner string, repo string, number int) (*github.PullRequest, *github.Response, error) {
+// mockChangesService's Get implementation, returning the listed pull request with the number, if any.
+func (m *mockChangesService) Get(ctx context.Context, owner string, repo string, number int) (*github.PullRequest, *github.Response, error) {
+	for _, pullRequest := range m.listPullRequests {
+		if pullRequest.GetNumber() == number {
+			return pullRequest, nil, nil
+		}
+	}
 	return nil, nil, nil
 }
 
+// mockChangesService's ListCommits implementation, returning each of the lists of commits as a page.
+func (m *mockChangesService) ListCommits(ctx context.Context, owner string, repo string, number int, opt *github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error) {
+	page := 1
+	if opt != nil && opt.Page > 0 {
+		page = opt.Page
+	}
+	if page > len(m.listCommits) {
+		return nil, &github.Response{}, nil
+	}
+	response := &github.Response{}
+	if page < len(m.listCommits) {
+		response.NextPage = page + 1
+	}
+	return m.listCommits[page-1], response, nil
+}
+
 // mockTicketsService is a mock for github.PullRequestsService.
 type mockTicketsService struct {
-	listIssueComments [][]github.IssueComment
+	listIssueComments [][]*github.IssueComment
+	created           int
+	edited            int
+	labels            []string
+	labelCalls        int
+	commentCalls      int
 }
 
 // newMockTicketsService creates a new TicketsService implementation.
-func newMockTicketsService(listIssueComments [][]github.IssueComment) *mockTicketsService {
+func newMockTicketsService(listIssueComments [][]*github.IssueComment) *mockTicketsService {
 	return &mockTicketsService{
 		listIssueComments: listIssueComments,
 	}
 }
 
-// mockTicketsService's List implementation.
-func (m *mockTicketsService) ListComments(owner string, repo string, number int, opt *github.IssueListCommentsOptions) ([]github.IssueComment, *github.Response, error) {
+// mockTicketsService's List implementation, returning each of the lists of comments as a page.
+func (m *mockTicketsService) ListComments(ctx context.Context, owner string, repo string, number int, opt *github.IssueListCommentsOptions) ([]*github.IssueComment, *github.Response, error) {
+	m.commentCalls++
+	page := 1
+	if opt != nil && opt.Page > 0 {
+		page = opt.Page
+	}
+	if page > len(m.listIssueComments) {
+		return nil, &github.Response{}, nil
+	}
+	response := &github.Response{}
+	if page < len(m.listIssueComments) {
+		response.NextPage = page + 1
+	}
+	return m.listIssueComments[page-1], response, nil
+}
+
+// mockTicketsService's CreateComment implementation.
+func (m *mockTicketsService) CreateComment(ctx context.Context, owner string, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	m.created++
+	return comment, nil, nil
+}
+
+// mockTicketsService's EditComment implementation.
+func (m *mockTicketsService) EditComment(ctx context.Context, owner string, repo string, id int64, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	m.edited++
+	return comment, nil, nil
+}
+
+// mockTicketsService's ListLabelsByIssue implementation.
+func (m *mockTicketsService) ListLabelsByIssue(ctx context.Context, owner string, repo string, number int, opt *github.ListOptions) ([]*github.Label, *github.Response, error) {
+	labels := make([]*github.Label, len(m.labels))
+	for n := range m.labels {
+		labels[n] = &github.Label{Name: &m.labels[n]}
+	}
+	return labels, nil, nil
+}
+
+// mockTicketsService's AddLabelsToIssue implementation.
+func (m *mockTicketsService) AddLabelsToIssue(ctx context.Context, owner string, repo string, number int, labels []string) ([]*github.Label, *github.Response, error) {
+	m.labelCalls++
+	m.labels = append(m.labels, labels...)
 	return nil, nil, nil
 }
 
+// mockTicketsService's RemoveLabelForIssue implementation.
+func (m *mockTicketsService) RemoveLabelForIssue(ctx context.Context, owner string, repo string, number int, label string) (*github.Response, error) {
+	m.labelCalls++
+	labels := []string{}
+	for _, l := range m.labels {
+		if l != label {
+			labels = append(labels, l)
+		}
+	}
+	m.labels = labels
+	return nil, nil
+}
+
+// mockUsersService is a mock for github.UsersService.
+type mockUsersService struct {
+	login string
+}
+
+// mockUsersService's Get implementation, returning the authenticated user.
+func (m *mockUsersService) Get(ctx context.Context, user string) (*github.User, *github.Response, error) {
+	return &github.User{Login: &m.login}, nil, nil
+}
+
 // Constructor for mockGHClient.
-func newMockGHClient(listPR []github.PullRequest, listIssueComments [][]github.IssueComment) *reviewer.GHClient {
-	client := &reviewer.GHClient{}
+func newMockGHClient(listPR []*github.PullRequest, listIssueComments [][]*github.IssueComment) *GHClient {
+	client := &GHClient{}
 	client.Changes = newMockChangesService(listPR)
 	client.Tickets = newMockTicketsService(listIssueComments)
+	client.Users = &mockUsersService{login: "reviewer-bot"}
 	return client
 }
 
@@ -80,16 +201,16 @@ func mockGetString(k string) string {
 }
 
 func TestGetGHAuth(t *testing.T) {
-	reviewer.GetString = mockGetString
+	GetString = mockGetString
 
 	var result interface{}
 	var errClient error
-	result, errClient = reviewer.GetClient()
+	result, errClient = GetClient()
 
 	if errClient != nil {
 		t.Fatalf("GetClient returned error(%s) when everything was ok", errClient)
 	}
-	v, err := result.(reviewer.GHClient)
+	v, err := result.(GHClient)
 	if err {
 		t.Fatalf("GetClient returned %s instead of github.Client", reflect.TypeOf(v))
 	}
@@ -111,25 +232,36 @@ func TestCommentSuccessScore(t *testing.T) {
 	testScore("Oops +1 :-1: +1", 0)
 }
 
-func newMockPullRequest(number int, title string, mergeable bool) github.PullRequest {
-	return github.PullRequest{
+func newMockPullRequest(number int, title string, mergeable bool) *github.PullRequest {
+	return &github.PullRequest{
 		Number:    &number,
 		Title:     &title,
 		Mergeable: &mergeable,
 	}
 }
 
+// newMockStatusServer points the client to a server answering the combined status of any commit, with no statuses,
+// as the statuses are got from the GitHub API itself. It must be closed.
+func newMockStatusServer(client *GHClient) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"state": "pending", "statuses": []}`))
+	}))
+	client.client = github.NewClient(nil)
+	client.client.BaseURL, _ = url.Parse(server.URL + "/")
+	return server
+}
+
 func TestGetPullRequestsInfo(t *testing.T) {
 	//TODO: https://github.com/gophergala2016/reviewer/issues/22
-	var emptyListPR []github.PullRequest
-	emptyListPR = make([]github.PullRequest, 0)
-	var emptyListIC [][]github.IssueComment
-	emptyListIC = make([][]github.IssueComment, 0)
+	var emptyListPR []*github.PullRequest
+	emptyListPR = make([]*github.PullRequest, 0)
+	var emptyListIC [][]*github.IssueComment
+	emptyListIC = make([][]*github.IssueComment, 0)
 	client := newMockGHClient(emptyListPR, emptyListIC)
 
-	var result []reviewer.PullRequestInfo
+	var result []PullRequestInfo
 	var err error
-	result, err = reviewer.GetPullRequestInfos(client, "user", "repo", []string{})
+	result, err = GetPullRequestInfos(context.Background(), client, "user", "repo", RepoPolicy{}, Filter{})
 
 	if err != nil {
 		t.Fatalf("Something went wrong when getting PR information")
@@ -138,11 +270,11 @@ func TestGetPullRequestsInfo(t *testing.T) {
 		t.Fatal("Got a populated list of PRInfos")
 	}
 
-	onePR := make([]github.PullRequest, 1)
+	onePR := make([]*github.PullRequest, 1)
 	onePR[0] = newMockPullRequest(10, "Initial PR", false)
 	client = newMockGHClient(onePR, emptyListIC)
 
-	result, err = reviewer.GetPullRequestInfos(client, "user", "repo", []string{})
+	result, err = GetPullRequestInfos(context.Background(), client, "user", "repo", RepoPolicy{}, Filter{})
 
 	if err != nil {
 		t.Fatalf("Something went wrong when getting PR information")
@@ -151,12 +283,12 @@ func TestGetPullRequestsInfo(t *testing.T) {
 		t.Fatal("Got a incorrect quantity of PRInfos:", len(result))
 	}
 
-	twoPR := make([]github.PullRequest, 2)
+	twoPR := make([]*github.PullRequest, 2)
 	twoPR[0] = newMockPullRequest(10, "Initial PR", true)
 	twoPR[1] = newMockPullRequest(11, "Not so initial PR", false)
 	client = newMockGHClient(twoPR, emptyListIC)
 
-	result, err = reviewer.GetPullRequestInfos(client, "user", "repo", []string{})
+	result, err = GetPullRequestInfos(context.Background(), client, "user", "repo", RepoPolicy{}, Filter{})
 
 	if err != nil {
 		t.Fatalf("Something went wrong when getting PR information")
@@ -164,6 +296,55 @@ func TestGetPullRequestsInfo(t *testing.T) {
 	if len(result) != 2 {
 		t.Fatal("Got a incorrect quantity of PRInfos:", len(result))
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = GetPullRequestInfos(ctx, client, "user", "repo", RepoPolicy{}, Filter{})
+	if err != context.Canceled {
+		t.Fatalf("Getting PR information with a cancelled context should fail, got %v", err)
+	}
+}
+
+func TestGetPullRequestInfoSummary(t *testing.T) {
+	summary := newMockComment("reviewer-bot", SummaryMarker+"\n### Reviewer summary")
+	summaryID := int64(7)
+	summary.ID = &summaryID
+	quote := newMockComment("reviewer1", "> "+SummaryMarker+"\n+1 ready to go")
+	quoteID := int64(8)
+	quote.ID = &quoteID
+	client := newMockGHClient(nil, [][]*github.IssueComment{{summary, quote}})
+	policy := RepoPolicy{Rules: Rules{Required: 1, Allowed: []string{"reviewer1"}}}
+
+	prInfo, err := GetPullRequestInfo(context.Background(), client, "user", "repo", newMockPullRequest(10, "Initial PR", true), policy, nil)
+	if err != nil {
+		t.Fatalf("GetPullRequestInfo returned error(%s)", err)
+	}
+	if prInfo.SummaryID != summaryID {
+		t.Fatalf("The summary should be the authenticated user's comment %v, got %v", summaryID, prInfo.SummaryID)
+	}
+	if prInfo.Score != 1 || prInfo.Comments != 1 {
+		t.Fatalf("Quoting the summary should still vote, got score %v from %v comments", prInfo.Score, prInfo.Comments)
+	}
+}
+
+func TestGetPullRequestInfoPages(t *testing.T) {
+	client := newMockGHClient(nil, [][]*github.IssueComment{
+		{newMockComment("reviewer1", "+1")},
+		{newMockComment("reviewer2", "+1"), newMockComment("reviewer3", "+1")},
+	})
+	client.Changes.(*mockChangesService).listCommits = [][]*github.RepositoryCommit{
+		{newMockCommit("author", "Initial commit")},
+		{newMockCommit("reviewer3", "Fix typo")},
+	}
+	policy := RepoPolicy{Rules: Rules{Required: 2, Allowed: []string{"reviewer1", "reviewer2", "reviewer3"}}}
+
+	prInfo, err := GetPullRequestInfo(context.Background(), client, "user", "repo", newMockPullRequest(10, "Initial PR", true), policy, nil)
+	if err != nil {
+		t.Fatalf("GetPullRequestInfo returned error(%s)", err)
+	}
+	if prInfo.Comments != 3 || prInfo.Score != 2 {
+		t.Fatalf("Votes in every page but the commit authors' should count, got score %v from %v comments", prInfo.Score, prInfo.Comments)
+	}
 }
 
 func TestIsMergeable(t *testing.T) {
@@ -172,7 +353,287 @@ func TestIsMergeable(t *testing.T) {
 	mergeable := true
 	pr := newMockPullRequest(id, title, mergeable)
 
-	if !reviewer.IsMergeable(&pr) {
+	if !IsMergeable(pr) {
 		t.Fatalf("PR #%d, %s should be mergeable", id, title)
 	}
 }
+
+func newMockStatus(context string, state string) *github.RepoStatus {
+	return &github.RepoStatus{
+		Context: &context,
+		State:   &state,
+	}
+}
+
+func TestCombineStatuses(t *testing.T) {
+	testCombine := func(statuses []*github.RepoStatus, expected string) {
+		state := CombineStatuses(statuses, nil)
+		if state != expected {
+			t.Fatalf("Bad combined state %v (expected %v) for %v statuses", state, expected, len(statuses))
+		}
+	}
+
+	testCombine([]*github.RepoStatus{}, "pending")
+	testCombine([]*github.RepoStatus{newMockStatus("ci", "success")}, "success")
+	testCombine([]*github.RepoStatus{newMockStatus("ci", "success"), newMockStatus("lint", "pending")}, "pending")
+	testCombine([]*github.RepoStatus{newMockStatus("ci", "pending"), newMockStatus("lint", "error")}, "failure")
+	testCombine([]*github.RepoStatus{newMockStatus("ci", "success"), newMockStatus(StatusContext, "pending")}, "success")
+	testCombine([]*github.RepoStatus{newMockStatus(StatusContext, "success")}, "pending")
+
+	contexts := []string{"ci"}
+	if CombineStatuses([]*github.RepoStatus{newMockStatus("ci", "success"), newMockStatus("lint", "failure")}, contexts) != "success" {
+		t.Fatal("Statuses not in contexts should be ignored")
+	}
+	if CombineStatuses([]*github.RepoStatus{newMockStatus("lint", "success")}, contexts) != "pending" {
+		t.Fatal("Missing contexts should be pending")
+	}
+}
+
+func TestDecisionStatus(t *testing.T) {
+	yes := true
+	no := false
+
+	testStatus := func(decision Decision, expected string) {
+		state, description := DecisionStatus(decision)
+		if state != expected {
+			t.Fatalf("Bad status %v (expected %v) for %v", state, expected, description)
+		}
+	}
+
+	testStatus(Decision{Action: ActionMerge, Mergeable: &yes, TestsState: "success"}, "success")
+	testStatus(Decision{Action: ActionNOP, Code: ReasonScore, Mergeable: &yes, TestsState: "success"}, "pending")
+	testStatus(Decision{Action: ActionNOP, Code: ReasonAutoMerge, Mergeable: &yes, TestsState: "success"}, "pending")
+	testStatus(Decision{Action: ActionHold, Code: ReasonSchedule, Mergeable: &yes, TestsState: "success"}, "pending")
+	testStatus(Decision{Action: ActionDeferred, Code: ReasonMaxMerges, Mergeable: &yes, TestsState: "success"}, "pending")
+	testStatus(Decision{Action: ActionNOP, Code: ReasonLabels}, "pending")
+	testStatus(Decision{Action: ActionNOP, Code: ReasonNotMergeable, TestsState: "success"}, "pending")
+	testStatus(Decision{Action: ActionNOP, Code: ReasonTests, Mergeable: &yes, TestsState: "failure"}, "failure")
+	testStatus(Decision{Action: ActionNOP, Code: ReasonNotMergeable, Mergeable: &no, TestsState: "success"}, "failure")
+	testStatus(Decision{Action: ActionNOP, Code: ReasonError}, "error")
+
+	_, description := DecisionStatus(Decision{Action: ActionNOP, Reason: "score 2 of 3 required"})
+	if description != "NOP: score 2 of 3 required" {
+		t.Fatalf("Bad status description: %v", description)
+	}
+	_, description = DecisionStatus(Decision{Action: ActionNOP, Reason: strings.Repeat("é", 200)})
+	if len([]rune(description)) != maxStatusDescription || !strings.HasSuffix(description, "...") {
+		t.Fatalf("Long status descriptions should be truncated, got %v", description)
+	}
+}
+
+func newMockComment(login string, body string) *github.IssueComment {
+	return &github.IssueComment{
+		Body: &body,
+		User: &github.User{Login: &login},
+	}
+}
+
+func TestCountVotes(t *testing.T) {
+	comments := []*github.IssueComment{
+		newMockComment("author", "+1 from me"),
+		newMockComment("reviewer1", "-1 needs tests"),
+		newMockComment("stranger", "+1"),
+		newMockComment("reviewer2", "LGTM"),
+		newMockComment("reviewer1", "Now it's fine +1"),
+		newMockComment("reviewer2", ":+1:"),
+	}
+
+	votes, score := countVotes(comments, map[string]string{"author": VoteSelf}, []string{"reviewer1", "reviewer2"})
+	if score != 2 {
+		t.Fatalf("Bad score %v (expected 2)", score)
+	}
+	expected := []string{VoteSelf, VoteStale, VoteNotAllowed, "", ""}
+	if len(votes) != len(expected) {
+		t.Fatalf("Got %v votes (expected %v)", len(votes), len(expected))
+	}
+	for n, vote := range votes {
+		if vote.Reason != expected[n] {
+			t.Fatalf("Vote %v from %v ignored for %q (expected %q)", n, vote.Login, vote.Reason, expected[n])
+		}
+	}
+}
+
+func TestIsDraft(t *testing.T) {
+	wip := regexp.MustCompile(DefaultWIPPattern)
+
+	testDraft := func(title string, draft bool, expected bool) {
+		pr := newMockPullRequest(1, title, true)
+		pr.Draft = &draft
+		if IsDraft(pr, wip) != expected {
+			t.Fatalf("PR %q (draft: %v) should have draft %v", title, draft, expected)
+		}
+	}
+
+	testDraft("Initial PR", false, false)
+	testDraft("Initial PR", true, true)
+	testDraft("WIP: Initial PR", false, true)
+	testDraft(" [WIP] Initial PR", false, true)
+	testDraft("Draft: Initial PR", false, true)
+	testDraft("Remove WIP support", false, false)
+}
+
+func TestIsFork(t *testing.T) {
+	upstream := "cooldeveloper/mycoolapp"
+	fork := "contributor/mycoolapp"
+
+	testFork := func(head *github.Repository, expected bool) {
+		pr := newMockPullRequest(1, "Initial PR", true)
+		pr.Base = &github.PullRequestBranch{Repo: &github.Repository{FullName: &upstream}}
+		pr.Head = &github.PullRequestBranch{Repo: head}
+		if IsFork(pr) != expected {
+			t.Fatalf("PR from %v should have fork %v", head, expected)
+		}
+	}
+
+	testFork(&github.Repository{FullName: &upstream}, false)
+	testFork(&github.Repository{FullName: &fork}, true)
+	testFork(nil, true)
+}
+
+func TestIgnoredVotes(t *testing.T) {
+	if IgnoredVotes([]Vote{{Login: "reviewer1", Score: 1}}) != "" {
+		t.Fatal("Without ignored votes nothing should be reported")
+	}
+	ignored := IgnoredVotes([]Vote{
+		{Login: "reviewer1", Score: 1},
+		{Login: "author-bot", Score: 1, Reason: VoteAlias},
+		{Login: "helper", Score: 1, Reason: VoteCoAuthor},
+	})
+	if ignored != " (ignored: author-bot alias of an author, helper commit author)" {
+		t.Fatalf("Bad ignored votes %q", ignored)
+	}
+}
+
+func TestApprovedSince(t *testing.T) {
+	start := time.Date(2016, 1, 23, 2, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	votes := []Vote{
+		{Login: "reviewer1", Score: 1, At: at(1)},
+		{Login: "reviewer2", Score: 1, At: at(2), Reason: VoteStale},
+		{Login: "stranger", Score: 1, At: at(3), Reason: VoteNotAllowed},
+		{Login: "reviewer2", Score: -1, At: at(4), Reason: VoteStale},
+		{Login: "reviewer2", Score: 1, At: at(5)},
+	}
+
+	if since := approvedSince(votes, 2); !since.Equal(at(5)) {
+		t.Fatalf("Approved since %v (expected %v)", since, at(5))
+	}
+	if since := approvedSince(votes[:2], 1); !since.Equal(at(1)) {
+		t.Fatalf("Approved since %v (expected %v)", since, at(1))
+	}
+	if since := approvedSince(votes, 3); !since.IsZero() {
+		t.Fatalf("Approved since %v without reaching the required score", since)
+	}
+}
+
+func TestApprovedSinceEdited(t *testing.T) {
+	start := time.Date(2016, 1, 23, 2, 0, 0, 0, time.UTC)
+	edited := newMockComment("reviewer1", "Looks good now, +1")
+	created := start
+	updated := start.Add(2 * time.Hour)
+	edited.CreatedAt, edited.UpdatedAt = &created, &updated
+	approved := newMockComment("reviewer2", "+1")
+	approvedAt := start.Add(time.Hour)
+	approved.CreatedAt, approved.UpdatedAt = &approvedAt, &approvedAt
+
+	votes, score := countVotes([]*github.IssueComment{edited, approved}, nil, []string{"reviewer1", "reviewer2"})
+	if score != 2 {
+		t.Fatalf("Bad score %v (expected 2)", score)
+	}
+	if since := approvedSince(votes, 2); !since.Equal(updated) {
+		t.Fatalf("Approved since %v (expected the edition %v)", since, updated)
+	}
+}
+
+func TestRemainingWait(t *testing.T) {
+	now := time.Date(2016, 1, 23, 2, 0, 0, 0, time.UTC)
+	prInfo := PullRequestInfo{
+		CreatedAt:  now.Add(-5 * time.Minute),
+		ApprovedAt: now.Add(-1 * time.Minute),
+	}
+
+	testWait := func(policy Rules, expected time.Duration) {
+		wait := RemainingWait(prInfo, policy, now)
+		if wait != expected {
+			t.Fatalf("Bad wait %v (expected %v)", wait, expected)
+		}
+	}
+
+	testWait(Rules{}, 0)
+	testWait(Rules{MinAge: time.Hour}, 55*time.Minute)
+	testWait(Rules{MinAge: time.Hour, MinApprovalAge: 2 * time.Hour}, 119*time.Minute)
+	testWait(Rules{MinAge: time.Minute, MinApprovalAge: time.Minute}, 0)
+}
+
+// configuredRepositories is a configuration file with two repositories.
+const configuredRepositories = `
+repositories:
+  mycoolapp:
+    username: cooldeveloper
+    status: true
+    required: 2
+    branch_policies:
+      release/*:
+        required: 3
+  legacy:
+    username: cooldeveloper
+    status: false
+    required: 1
+`
+
+// newConfiguredRepositories returns the repositories of configuredRepositories, read by Viper.
+func newConfiguredRepositories(t *testing.T) *Config {
+	config := viper.New()
+	config.SetConfigType("yaml")
+	if err := config.ReadConfig(strings.NewReader(configuredRepositories)); err != nil {
+		t.Fatal(err)
+	}
+	return NewConfig(config.Sub("repositories"))
+}
+
+func TestRunConfiguredRepositories(t *testing.T) {
+	repositories := newConfiguredRepositories(t)
+	defer func(stdout io.Writer) { Stdout = stdout }(Stdout)
+
+	testRun := func(options Options, expected string) {
+		var output bytes.Buffer
+		Stdout = &output
+		err := run(context.Background(), newMockGHClient(nil, nil), repositories, nil, nil, options)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if output.String() != expected {
+			t.Fatalf("Bad report %q (expected %q)", output.String(), expected)
+		}
+	}
+
+	testRun(Options{DryRun: true}, "- cooldeveloper/legacy Discarded (repo disabled)\n+ cooldeveloper/mycoolapp\n")
+	testRun(Options{DryRun: true, Targets: []Target{{Owner: "cooldeveloper", Repo: "mycoolapp"}}}, "+ cooldeveloper/mycoolapp\n")
+
+	stop := make(chan struct{})
+	close(stop)
+	testRun(Options{DryRun: true, Stop: stop}, "")
+}
+
+func TestRunPolicies(t *testing.T) {
+	repositories := newConfiguredRepositories(t)
+	pullRequest, sha := newMockPullRequest(1, "Adds login", true), "abc123"
+	pullRequest.Head = &github.PullRequestBranch{SHA: &sha}
+	client := newMockGHClient([]*github.PullRequest{pullRequest}, nil)
+	server := newMockStatusServer(client)
+	defer server.Close()
+	defer func(stdout io.Writer) { Stdout = stdout }(Stdout)
+	var output bytes.Buffer
+	Stdout = &output
+
+	options := Options{DryRun: true, Output: OutputJSONL, Policies: []Policy{jiraPolicy{}}}
+	if err := run(context.Background(), client, repositories, nil, nil, options); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !strings.Contains(output.String(), `"reason_code":"jira"`) {
+		t.Fatalf("The policies of the options should be checked, got %v", output.String())
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// junitFailure is the failure of a JUnit test case.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitTestCase is a pull request in a JUnit report.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+// junitTestSuite is a repository in a JUnit report.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestSuites is the root of a JUnit report.
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Timestamp  string           `xml:"timestamp,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestCaseFor returns the test case of the pull request, passed when it's merged or would be,
+// skipped for drafts, errored when it couldn't be merged or evaluated, and failed with the reason otherwise.
+func junitTestCaseFor(suite string, result Result) junitTestCase {
+	testCase := junitTestCase{
+		Name:      fmt.Sprintf("#%v %v", result.Number, result.Title),
+		ClassName: suite,
+	}
+	switch {
+	case result.Action == ActionSkip:
+		testCase.Skipped = &struct{}{}
+	case result.MergeErr != nil:
+		testCase.Error = &junitFailure{Message: fmt.Sprintf("Merge failed: %v", result.MergeErr), Type: "merge"}
+	case result.Err != nil:
+		testCase.Error = &junitFailure{Message: result.Reason, Type: ReasonError, Text: result.Err.Error()}
+	case result.Action != ActionMerge:
+		testCase.Failure = &junitFailure{Message: result.Reason, Type: result.Code, Text: fmt.Sprintf("%v %v", result.Action, result.Reason)}
+	}
+	return testCase
+}
+
+// junitRenderer renders the report as JUnit XML, with a test suite per repository and a test case per pull request.
+type junitRenderer struct{}
+
+func (junitRenderer) Execute(w io.Writer, data interface{}) error {
+	report := data.(report)
+	suites := junitTestSuites{Name: "reviewer", Timestamp: report.Generated.Format("2006-01-02T15:04:05")}
+	for _, repository := range report.Repositories {
+		suite := junitTestSuite{Name: fmt.Sprintf("%v/%v", repository.Owner, repository.Repo), TestCases: []junitTestCase{}}
+		switch repository.Discarded {
+		case "":
+		case DiscardedDisabled:
+			suite.TestCases = append(suite.TestCases, junitTestCase{Name: "repository", ClassName: suite.Name, Skipped: &struct{}{}})
+		default:
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      "repository",
+				ClassName: suite.Name,
+				Error:     &junitFailure{Message: "Discarded: " + repository.Discarded, Type: "discarded"},
+			})
+		}
+		for _, result := range repository.PullRequests {
+			suite.TestCases = append(suite.TestCases, junitTestCaseFor(suite.Name, result))
+		}
+		for _, testCase := range suite.TestCases {
+			suite.Tests++
+			switch {
+			case testCase.Skipped != nil:
+				suite.Skipped++
+			case testCase.Error != nil:
+				suite.Errors++
+			case testCase.Failure != nil:
+				suite.Failures++
+			}
+		}
+		suites.TestSuites = append(suites.TestSuites, suite)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,144 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Review states which can be mapped to labels through the repository's labels setting.
+const (
+	LabelNeedsReview      = "needs-review"
+	LabelApproved         = "approved"
+	LabelChangesRequested = "changes-requested"
+	LabelCIFailing        = "ci-failing"
+	LabelMerged           = "merged-by-reviewer"
+)
+
+// LabelStates returns the review states of a pull request.
+func LabelStates(prInfo PullRequestInfo, required int, testsState string) []string {
+	states := []string{}
+	if prInfo.Score < required {
+		states = append(states, LabelNeedsReview)
+	} else {
+		states = append(states, LabelApproved)
+	}
+	for _, vote := range prInfo.Votes {
+		if vote.Reason == "" && vote.Score < 0 {
+			states = append(states, LabelChangesRequested)
+			break
+		}
+	}
+	if testsState == "failure" {
+		states = append(states, LabelCIFailing)
+	}
+	return states
+}
+
+// hasLabel returns true if the label is in the list, ignoring case as GitHub does.
+func hasLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if strings.EqualFold(l, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckLabels returns the action and the reason when the pull request is gated by its labels, or empty strings if it isn't.
+// It's never merged, NOP, if it has any of the skip labels, and not considered, SKIP, if it lacks any of the required labels.
+func CheckLabels(labels []string, skipLabels []string, requireLabels []string) (string, string) {
+	for _, label := range skipLabels {
+		if hasLabel(labels, label) {
+			return ActionNOP, fmt.Sprintf("Blocked by label %v", label)
+		}
+	}
+	for _, label := range requireLabels {
+		if !hasLabel(labels, label) {
+			return ActionSkip, fmt.Sprintf("Missing required label %v", label)
+		}
+	}
+	return "", ""
+}
+
+// labelChanges returns the labels to add and to remove so the pull request has only the labels mapped from its states.
+// Labels not present in the mapping are never removed.
+func labelChanges(current []string, mapping map[string]string, states []string) ([]string, []string) {
+	wanted := make(map[string]bool)
+	for _, state := range states {
+		if label, exists := mapping[state]; exists && label != "" {
+			wanted[label] = true
+		}
+	}
+	has := make(map[string]bool)
+	for _, label := range current {
+		has[label] = true
+	}
+
+	add := []string{}
+	for label := range wanted {
+		if !has[label] {
+			add = append(add, label)
+		}
+	}
+	remove := []string{}
+	for _, label := range mapping {
+		if has[label] && !wanted[label] {
+			remove = append(remove, label)
+			has[label] = false
+		}
+	}
+	sort.Strings(add)
+	sort.Strings(remove)
+	return add, remove
+}
+
+// GetLabels returns the names of the labels of a pull request.
+func GetLabels(ctx context.Context, client *GHClient, owner string, repo string, number int) ([]string, error) {
+	labels, _, err := client.Tickets.ListLabelsByIssue(ctx, owner, repo, number, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		if label.Name != nil {
+			names = append(names, *label.Name)
+		}
+	}
+	return names, nil
+}
+
+// SyncLabels sets the labels mapped from the states on a pull request, only calling the API when they change.
+func SyncLabels(ctx context.Context, client *GHClient, owner string, repo string, number int, mapping map[string]string, states []string) error {
+	current, err := GetLabels(ctx, client, owner, repo, number)
+	if err != nil {
+		return err
+	}
+	add, remove := labelChanges(current, mapping, states)
+	if len(add) > 0 {
+		if _, _, err := client.Tickets.AddLabelsToIssue(ctx, owner, repo, number, add); err != nil {
+			return err
+		}
+	}
+	for _, label := range remove {
+		if _, err := client.Tickets.RemoveLabelForIssue(ctx, owner, repo, number, label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLabelStates(t *testing.T) {
+	testStates := func(prInfo PullRequestInfo, testsState string, expected []string) {
+		states := LabelStates(prInfo, 2, testsState)
+		if !reflect.DeepEqual(states, expected) {
+			t.Fatalf("Bad states %v (expected %v)", states, expected)
+		}
+	}
+
+	testStates(PullRequestInfo{Score: 0}, "pending", []string{LabelNeedsReview})
+	testStates(PullRequestInfo{Score: 2}, "success", []string{LabelApproved})
+	testStates(PullRequestInfo{
+		Score: 0,
+		Votes: []Vote{{Login: "reviewer1", Score: -1}, {Login: "reviewer2", Score: 1}},
+	}, "failure", []string{LabelNeedsReview, LabelChangesRequested, LabelCIFailing})
+	testStates(PullRequestInfo{
+		Score: 2,
+		Votes: []Vote{{Login: "stranger", Score: -1, Reason: VoteNotAllowed}},
+	}, "success", []string{LabelApproved})
+}
+
+func TestSyncLabels(t *testing.T) {
+	client := newMockGHClient(nil, nil)
+	tickets := client.Tickets.(*mockTicketsService)
+	tickets.labels = []string{"bug", "needs-review"}
+	mapping := map[string]string{
+		LabelNeedsReview: "needs-review",
+		LabelApproved:    "approved",
+	}
+
+	err := SyncLabels(context.Background(), client, "user", "repo", 1, mapping, []string{LabelNeedsReview, LabelCIFailing})
+	if err != nil {
+		t.Fatalf("SyncLabels returned error(%s)", err)
+	}
+	if tickets.labelCalls != 0 {
+		t.Fatalf("Unchanged labels made %v API calls", tickets.labelCalls)
+	}
+
+	err = SyncLabels(context.Background(), client, "user", "repo", 1, mapping, []string{LabelApproved})
+	if err != nil {
+		t.Fatalf("SyncLabels returned error(%s)", err)
+	}
+	sort.Strings(tickets.labels)
+	if !reflect.DeepEqual(tickets.labels, []string{"approved", "bug"}) {
+		t.Fatalf("Bad labels %v after approval", tickets.labels)
+	}
+}
+
+func TestCheckLabels(t *testing.T) {
+	skip := []string{"do-not-merge", "wip"}
+	require := []string{"ready"}
+
+	testCheck := func(labels []string, expectedAction string, expected string) {
+		action, reason := CheckLabels(labels, skip, require)
+		if action != expectedAction || reason != expected {
+			t.Fatalf("Bad gate %v %q (expected %v %q) for labels %v", action, reason, expectedAction, expected, labels)
+		}
+	}
+
+	testCheck([]string{"ready"}, "", "")
+	testCheck([]string{"bug", "Ready"}, "", "")
+	testCheck([]string{}, ActionSkip, "Missing required label ready")
+	testCheck([]string{"ready", "WIP"}, ActionNOP, "Blocked by label wip")
+	testCheck([]string{"do-not-merge"}, ActionNOP, "Blocked by label do-not-merge")
+}
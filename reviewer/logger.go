@@ -0,0 +1,102 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger receives the diagnostics of the package, by level.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// Levels of the diagnostics.
+const (
+	LevelDebug = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// Formats of the diagnostics.
+const (
+	LogText = "text"
+	LogJSON = "json"
+)
+
+// Log contains the logger used for diagnostics. Embedding programs can replace it with their own.
+var Log Logger = NewLogger(os.Stderr, LevelInfo, LogText)
+
+// Stdout contains the writer reports are written to. Embedding programs can replace it.
+var Stdout io.Writer = os.Stdout
+
+// ParseLevel returns the level with the name.
+func ParseLevel(name string) (int, error) {
+	for level, levelName := range levelNames {
+		if strings.ToLower(name) == levelName {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown log level %q, expected one of %v", name, strings.Join(levelNames, ", "))
+}
+
+// writerLogger writes the diagnostics from its level on, as text lines or JSON objects.
+type writerLogger struct {
+	w      io.Writer
+	level  int
+	format string
+	mutex  sync.Mutex
+}
+
+// NewLogger returns a logger writing the diagnostics from the level on to w, in the format.
+func NewLogger(w io.Writer, level int, format string) Logger {
+	return &writerLogger{w: w, level: level, format: format}
+}
+
+func (l *writerLogger) log(level int, format string, args ...interface{}) {
+	if level < l.level {
+		return
+	}
+	now := Now().Format(time.RFC3339)
+	message := fmt.Sprintf(format, args...)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.format == LogJSON {
+		json.NewEncoder(l.w).Encode(struct {
+			Time    string `json:"time"`
+			Level   string `json:"level"`
+			Message string `json:"msg"`
+		}{now, levelNames[level], message})
+		return
+	}
+	fmt.Fprintf(l.w, "%v %-5v %v\n", now, strings.ToUpper(levelNames[level]), message)
+}
+
+func (l *writerLogger) Debugf(format string, args ...interface{}) { l.log(LevelDebug, format, args...) }
+func (l *writerLogger) Infof(format string, args ...interface{})  { l.log(LevelInfo, format, args...) }
+func (l *writerLogger) Warnf(format string, args ...interface{})  { l.log(LevelWarn, format, args...) }
+func (l *writerLogger) Errorf(format string, args ...interface{}) { l.log(LevelError, format, args...) }
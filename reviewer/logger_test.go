@@ -0,0 +1,60 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseLevel(t *testing.T) {
+	for name, expected := range map[string]int{"debug": LevelDebug, "INFO": LevelInfo, "warn": LevelWarn, "error": LevelError} {
+		level, err := ParseLevel(name)
+		if err != nil || level != expected {
+			t.Errorf("Level %v should be %v, got %v (%v)", name, expected, level, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Unknown levels should fail")
+	}
+}
+
+func TestLogger(t *testing.T) {
+	defer func() { Now = time.Now }()
+	Now = func() time.Time { return time.Date(2016, 1, 23, 10, 0, 0, 0, time.UTC) }
+
+	var output bytes.Buffer
+	logger := NewLogger(&output, LevelInfo, LogText)
+	logger.Debugf("hidden %v", 1)
+	logger.Infof("Listening on %v", ":8080")
+	logger.Errorf("boom")
+	expected := "2016-01-23T10:00:00Z INFO  Listening on :8080\n2016-01-23T10:00:00Z ERROR boom\n"
+	if output.String() != expected {
+		t.Fatalf("Bad text log %q, expected %q", output.String(), expected)
+	}
+
+	output.Reset()
+	logger = NewLogger(&output, LevelDebug, LogJSON)
+	logger.Warnf("Error reloading config file: %v", "bad yaml")
+	var record map[string]string
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["time"] != "2016-01-23T10:00:00Z" || record["level"] != "warn" || record["msg"] != "Error reloading config file: bad yaml" {
+		t.Fatalf("Bad JSON log %v", record)
+	}
+}
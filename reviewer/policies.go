@@ -0,0 +1,82 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// Candidate is a pull request being evaluated, as given to the policies.
+type Candidate struct {
+	PullRequestInfo                     // with the votes
+	PullRequest     *github.PullRequest // as got from GitHub
+	Statuses        []*github.RepoStatus
+	TestsState      string // combined state of the required status contexts
+	Rules           Rules  // effective rules
+}
+
+// Policy decides whether a pull request can be merged.
+type Policy interface {
+	// Name returns the name of the policy, shown in the decision's trace, and used as reason code when it fails.
+	Name() string
+	// Check returns whether the candidate passes the policy, and the reason when it doesn't.
+	Check(candidate Candidate) (bool, string)
+}
+
+// gate is a built-in policy, with its own reason code.
+type gate struct {
+	name  string
+	code  string
+	check func(candidate Candidate) (bool, string)
+}
+
+func (g gate) Name() string {
+	return g.name
+}
+
+func (g gate) Check(candidate Candidate) (bool, string) {
+	return g.check(candidate)
+}
+
+// reasonCode returns the reason code of the policy when it fails.
+func reasonCode(policy Policy) string {
+	if g, ok := policy.(gate); ok {
+		return g.code
+	}
+	return policy.Name()
+}
+
+// MergeablePolicy passes pull requests GitHub can merge.
+var MergeablePolicy Policy = gate{"mergeable", ReasonNotMergeable, func(candidate Candidate) (bool, string) {
+	return IsMergeable(candidate.PullRequest), "Not mergeable"
+}}
+
+// TestsPolicy passes pull requests whose required status contexts succeeded.
+var TestsPolicy Policy = gate{"tests", ReasonTests, func(candidate Candidate) (bool, string) {
+	return candidate.TestsState == "success", "Tests not passed"
+}}
+
+// ScorePolicy passes pull requests with the required score.
+var ScorePolicy Policy = gate{"score", ReasonScore, func(candidate Candidate) (bool, string) {
+	reason := fmt.Sprintf("score %v of %v required", candidate.Score, candidate.Rules.Required)
+	return candidate.Score >= candidate.Rules.Required, reason + IgnoredVotes(candidate.Votes)
+}}
+
+// DefaultPolicies returns the built-in policies, checked when no others are given.
+func DefaultPolicies() []Policy {
+	return []Policy{MergeablePolicy, TestsPolicy, ScorePolicy}
+}
@@ -0,0 +1,102 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"github.com/google/go-github/v32/github"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// jiraPolicy passes pull requests referencing a Jira key in the title.
+type jiraPolicy struct{}
+
+func (jiraPolicy) Name() string {
+	return "jira"
+}
+
+func (jiraPolicy) Check(candidate Candidate) (bool, string) {
+	return regexp.MustCompile(`\b[A-Z]+-[0-9]+\b`).MatchString(candidate.Title), "Missing Jira key in the title"
+}
+
+func TestBuiltInPolicies(t *testing.T) {
+	mergeable, unmergeable := true, false
+	tests := []struct {
+		policy    Policy
+		candidate Candidate
+		passed    bool
+		reason    string
+	}{
+		{MergeablePolicy, Candidate{PullRequest: &github.PullRequest{Mergeable: &mergeable}}, true, "Not mergeable"},
+		{MergeablePolicy, Candidate{PullRequest: &github.PullRequest{Mergeable: &unmergeable}}, false, "Not mergeable"},
+		{TestsPolicy, Candidate{TestsState: "success"}, true, "Tests not passed"},
+		{TestsPolicy, Candidate{TestsState: "pending"}, false, "Tests not passed"},
+		{ScorePolicy, Candidate{PullRequestInfo: PullRequestInfo{Score: 2}, Rules: Rules{Required: 2}}, true, "score 2 of 2 required"},
+		{ScorePolicy, Candidate{PullRequestInfo: PullRequestInfo{Score: 1}, Rules: Rules{Required: 2}}, false, "score 1 of 2 required"},
+	}
+	for _, test := range tests {
+		passed, reason := test.policy.Check(test.candidate)
+		if passed != test.passed || reason != test.reason {
+			t.Errorf("Policy %v checked %v (%v), expected %v (%v)", test.policy.Name(), passed, reason, test.passed, test.reason)
+		}
+	}
+}
+
+func TestEnginePolicies(t *testing.T) {
+	names := []string{}
+	for _, policy := range NewEngine(nil, Settings{}).settings.Policies {
+		names = append(names, policy.Name())
+	}
+	expected := []string{"mergeable", "tests", "score"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected default policies %v, got %v", expected, names)
+	}
+	for i := range names {
+		if names[i] != expected[i] {
+			t.Fatalf("Expected default policies %v, got %v", expected, names)
+		}
+	}
+
+	repository := Repository{Owner: "user", Name: "repo", Filter: Filter{WIP: regexp.MustCompile(DefaultWIPPattern)}}
+	pullRequest, sha := newMockPullRequest(1, "Adds login", true), "abc123"
+	pullRequest.Head = &github.PullRequestBranch{SHA: &sha}
+	client := newMockGHClient([]*github.PullRequest{pullRequest}, nil)
+	server := newMockStatusServer(client)
+	defer server.Close()
+	engine := NewEngine(client, Settings{Policies: append(DefaultPolicies(), jiraPolicy{})})
+	decisions, err := engine.Evaluate(context.Background(), repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 1 || !strings.Contains(strings.Join(decisions[0].Trace, "\n"), "jira: FAIL") {
+		t.Fatalf("The engine's policies should be checked, got %v", decisions)
+	}
+	decisions, _ = NewEngine(client, Settings{}).Evaluate(context.Background(), repository)
+	if len(decisions) != 1 || strings.Contains(strings.Join(decisions[0].Trace, "\n"), "jira") {
+		t.Fatalf("Policies of other engines shouldn't be checked, got %v", decisions)
+	}
+
+	if code := reasonCode(jiraPolicy{}); code != "jira" {
+		t.Errorf("Other policies should use their name as reason code, got %v", code)
+	}
+	if code := reasonCode(MergeablePolicy); code != ReasonNotMergeable {
+		t.Errorf("Built-in policies should use their reason code, got %v", code)
+	}
+	if passed, _ := (jiraPolicy{}).Check(Candidate{PullRequestInfo: PullRequestInfo{Title: "PROJ-42 Adds login"}}); !passed {
+		t.Error("Title with a Jira key should pass")
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// ConfigReader is an interface for getting typed values of Viper's keys
+type ConfigReader interface {
+	ConfigRepositoriesChecker
+	IsSet(key string) bool
+	GetBool(key string) bool
+	GetInt(key string) int
+	GetStringSlice(key string) []string
+	GetDuration(key string) time.Duration
+	GetStringMap(key string) map[string]interface{}
+	GetStringMapString(key string) map[string]string
+}
+
+// Rules contains the rules for merging pull requests.
+type Rules struct {
+	Required       int      // score required to merge
+	Allowed        []string // logins of the reviewers whose votes count
+	MergeMethod    string   // merge, squash or rebase
+	Contexts       []string // status contexts required to succeed, all of them when empty
+	Maintainers    []string // at least one of them must approve, when set
+	NoAutoMerge    bool     // the pull requests are never merged automatically, false as the zero value
+	Authors        AuthorRules
+	MinAge         time.Duration // since the pull request was opened
+	MinApprovalAge time.Duration // since the score reached the required one
+}
+
+// RepoPolicy contains the policy of a repository and its overrides by base branch.
+type RepoPolicy struct {
+	Rules
+	Branches  map[string]Rules // effective policies keyed by base branch glob
+	Fork      *RepoPolicy      // policies for pull requests from forks, if any
+	Aliases   [][]string       // groups of logins belonging to the same person
+	Schedule  *Schedule        // when merges are allowed, always if nil
+	MaxMerges int              // maximum merges per run, unlimited if 0
+}
+
+// For returns the effective policy for pull requests into the base branch.
+func (r RepoPolicy) For(branch string) Rules {
+	patterns := make([]string, 0, len(r.Branches))
+	for pattern := range r.Branches {
+		patterns = append(patterns, pattern)
+	}
+	if pattern, found := MatchBranch(patterns, branch); found {
+		return r.Branches[pattern]
+	}
+	return r.Rules
+}
+
+// ForPullRequest returns the effective policy for the pull request, taking into account whether it comes from a fork.
+func (r RepoPolicy) ForPullRequest(prInfo PullRequestInfo) Rules {
+	if prInfo.Fork && r.Fork != nil {
+		return r.Fork.For(prInfo.Base)
+	}
+	return r.For(prInfo.Base)
+}
+
+// configKey returns the key of the setting under prefix, or the key itself without prefix.
+func configKey(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// overridePolicy returns the policy with the values set under prefix, or at the top level without prefix, overridden.
+func overridePolicy(config ConfigReader, prefix string, policy Rules) (Rules, error) {
+	key := func(name string) string {
+		return configKey(prefix, name)
+	}
+	if config.IsSet(key("required")) {
+		policy.Required = config.GetInt(key("required"))
+	}
+	if config.IsSet(key("allowed")) {
+		policy.Allowed = config.GetStringSlice(key("allowed"))
+	}
+	if config.IsSet(key("merge_method")) {
+		policy.MergeMethod = config.GetString(key("merge_method"))
+	}
+	if config.IsSet(key("contexts")) {
+		policy.Contexts = config.GetStringSlice(key("contexts"))
+	}
+	if config.IsSet(key("maintainers")) {
+		policy.Maintainers = config.GetStringSlice(key("maintainers"))
+	}
+	if config.IsSet(key("auto_merge")) {
+		policy.NoAutoMerge = !config.GetBool(key("auto_merge"))
+	}
+	if config.IsSet(key("min_age")) {
+		policy.MinAge = config.GetDuration(key("min_age"))
+	}
+	if config.IsSet(key("min_approval_age")) {
+		policy.MinApprovalAge = config.GetDuration(key("min_approval_age"))
+	}
+	if config.IsSet(key("authors.allow")) {
+		policy.Authors.Allow = config.GetStringSlice(key("authors.allow"))
+	}
+	if config.IsSet(key("authors.deny")) {
+		policy.Authors.Deny = config.GetStringSlice(key("authors.deny"))
+	}
+	if policy.Required < 0 {
+		return policy, fmt.Errorf("Invalid required %v", policy.Required)
+	}
+	switch policy.MergeMethod {
+	case "merge", "squash", "rebase":
+	default:
+		return policy, fmt.Errorf("Invalid merge_method %q", policy.MergeMethod)
+	}
+	return policy, nil
+}
+
+// mapConfig returns the settings in the map value of a setting, so that they aren't looked up by a key containing
+// the setting's name, which may have dots, as branch patterns.
+func mapConfig(value interface{}) (ConfigReader, error) {
+	values, err := cast.ToStringMapE(value)
+	if err != nil {
+		return nil, err
+	}
+	config := viper.New()
+	if err := config.MergeConfigMap(values); err != nil {
+		return nil, err
+	}
+	return NewConfig(config), nil
+}
+
+// LoadPolicy returns the policy of the repository, resolving the per branch overrides in branches.required and branch_policies,
+// and the overrides for pull requests from forks in fork_policy.
+func LoadPolicy(config ConfigReader, repoName string) (RepoPolicy, error) {
+	var err error
+	policy := RepoPolicy{Branches: make(map[string]Rules)}
+	policy.Rules, err = overridePolicy(config, repoName, Rules{MergeMethod: "merge"})
+	if err != nil {
+		return policy, fmt.Errorf("%v in %v", err, repoName)
+	}
+	// patterns may contain dots, so their values are taken from the map rather than looked up by key
+	for pattern, value := range config.GetStringMap(repoName + ".branches.required") {
+		branchPolicy := policy.Rules
+		branchPolicy.Required, err = cast.ToIntE(value)
+		if err != nil || branchPolicy.Required < 0 {
+			return policy, fmt.Errorf("Invalid required %v for branch %v in %v.branches.required", value, pattern, repoName)
+		}
+		policy.Branches[pattern] = branchPolicy
+	}
+	for pattern, value := range config.GetStringMap(repoName + ".branch_policies") {
+		branchPolicy, exists := policy.Branches[pattern]
+		if !exists {
+			branchPolicy = policy.Rules
+		}
+		branchConfig, err := mapConfig(value)
+		if err != nil {
+			return policy, fmt.Errorf("Invalid policy for branch %v in %v.branch_policies: %v", pattern, repoName, err)
+		}
+		policy.Branches[pattern], err = overridePolicy(branchConfig, "", branchPolicy)
+		if err != nil {
+			return policy, fmt.Errorf("%v in %v.branch_policies.%v", err, repoName, pattern)
+		}
+	}
+	policy.MaxMerges = config.GetInt(repoName + ".max_merges_per_run")
+	policy.Schedule, err = LoadSchedule(config, repoName+".schedule")
+	if err != nil {
+		return policy, err
+	}
+	if config.IsSet(repoName + ".fork_policy") {
+		prefix := repoName + ".fork_policy"
+		fork := RepoPolicy{Branches: make(map[string]Rules)}
+		fork.Rules, err = overridePolicy(config, prefix, policy.Rules)
+		if err != nil {
+			return policy, fmt.Errorf("%v in %v", err, prefix)
+		}
+		fork.Rules = stricter(policy.Rules, fork.Rules)
+		for pattern, branchPolicy := range policy.Branches {
+			forkPolicy, err := overridePolicy(config, prefix, branchPolicy)
+			if err != nil {
+				return policy, fmt.Errorf("%v in %v", err, prefix)
+			}
+			fork.Branches[pattern] = stricter(branchPolicy, forkPolicy)
+		}
+		policy.Fork = &fork
+	}
+	return policy, nil
+}
+
+// stricter returns the overridden policy, never looser than the policy it overrides: it requires the highest score and ages,
+// merges automatically only if both do, requires the contexts and maintainers of both, counts the votes of the reviewers
+// allowed by both, denies the authors denied by any, and allows the authors allowed by both when both restrict them.
+func stricter(policy Rules, overridden Rules) Rules {
+	if policy.Required > overridden.Required {
+		overridden.Required = policy.Required
+	}
+	if policy.MinAge > overridden.MinAge {
+		overridden.MinAge = policy.MinAge
+	}
+	if policy.MinApprovalAge > overridden.MinApprovalAge {
+		overridden.MinApprovalAge = policy.MinApprovalAge
+	}
+	overridden.NoAutoMerge = policy.NoAutoMerge || overridden.NoAutoMerge
+	if len(policy.Contexts) == 0 || len(overridden.Contexts) == 0 {
+		// no contexts means all of them
+		overridden.Contexts = nil
+	} else {
+		overridden.Contexts = union(policy.Contexts, overridden.Contexts)
+	}
+	overridden.Maintainers = union(policy.Maintainers, overridden.Maintainers)
+	overridden.Allowed = intersection(policy.Allowed, overridden.Allowed)
+	overridden.Authors.Deny = union(policy.Authors.Deny, overridden.Authors.Deny)
+	if overridden.Authors.Allow == nil {
+		overridden.Authors.Allow = policy.Authors.Allow
+	} else if policy.Authors.Allow != nil {
+		// an empty list allows nobody
+		overridden.Authors.Allow = intersection(policy.Authors.Allow, overridden.Authors.Allow)
+	}
+	return overridden
+}
+
+// intersection returns the values in both lists, in the order of the first one and without repetitions.
+func intersection(a []string, b []string) []string {
+	values := []string{}
+	for _, value := range union(a, nil) {
+		if hasLogin(b, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// union returns the values in any of the lists, in order and without repetitions.
+func union(a []string, b []string) []string {
+	var values []string
+	seen := make(map[string]bool)
+	for _, value := range append(append([]string{}, a...), b...) {
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// WithinLimit returns true if another merge is allowed, given the maximum merges, unlimited if 0, and the ones done.
+func WithinLimit(max int, merges int) bool {
+	return max <= 0 || merges < max
+}
+
+// ApprovedByMaintainer returns true if no maintainers are required, or any of them approved the pull request.
+func ApprovedByMaintainer(votes []Vote, maintainers []string) bool {
+	if len(maintainers) == 0 {
+		return true
+	}
+	for _, vote := range votes {
+		if vote.Reason == "" && vote.Score > 0 && hasLogin(maintainers, vote.Login) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLogin returns true if the login is in the list.
+func hasLogin(logins []string, login string) bool {
+	for _, l := range logins {
+		if l == login {
+			return true
+		}
+	}
+	return false
+}
+
+// policyLine returns the policy in a single line.
+func policyLine(policy Rules) string {
+	contexts := "all"
+	if len(policy.Contexts) > 0 {
+		contexts = strings.Join(policy.Contexts, ",")
+	}
+	line := fmt.Sprintf("+1:%v allowed:%v merge:%v contexts:%v", policy.Required, strings.Join(policy.Allowed, ","), policy.MergeMethod, contexts)
+	if len(policy.Maintainers) > 0 {
+		line += " maintainers:" + strings.Join(policy.Maintainers, ",")
+	}
+	if policy.NoAutoMerge {
+		line += " auto-merge:disabled"
+	}
+	if policy.MinAge > 0 {
+		line += fmt.Sprintf(" min-age:%v", policy.MinAge)
+	}
+	if policy.MinApprovalAge > 0 {
+		line += fmt.Sprintf(" min-approval-age:%v", policy.MinApprovalAge)
+	}
+	if len(policy.Authors.Allow) > 0 {
+		line += " authors:" + strings.Join(policy.Authors.Allow, ",")
+	}
+	if len(policy.Authors.Deny) > 0 {
+		line += " denied:" + strings.Join(policy.Authors.Deny, ",")
+	}
+	return line
+}
+
+// PolicyTable returns the resolved policies of the repository, one per line.
+func PolicyTable(repoName string, policy RepoPolicy) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "  %v\n", repoName)
+	fmt.Fprintf(&buf, "    %-20v %v\n", "(default)", policyLine(policy.Rules))
+	patterns := make([]string, 0, len(policy.Branches))
+	for pattern := range policy.Branches {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		fmt.Fprintf(&buf, "    %-20v %v\n", pattern, policyLine(policy.Branches[pattern]))
+	}
+	if policy.Fork != nil {
+		fmt.Fprintf(&buf, "    %-20v %v\n", "(fork)", policyLine(policy.Fork.Rules))
+		for _, pattern := range patterns {
+			fmt.Fprintf(&buf, "    %-20v %v\n", pattern+" (fork)", policyLine(policy.Fork.Branches[pattern]))
+		}
+	}
+	return buf.String()
+}
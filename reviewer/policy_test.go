@@ -0,0 +1,296 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// mockMapConfig is a ConfigReader backed by a map of keys to values.
+type mockMapConfig map[string]interface{}
+
+func (m mockMapConfig) AllKeys() []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (m mockMapConfig) IsSet(key string) bool {
+	_, exists := m[key]
+	return exists
+}
+
+func (m mockMapConfig) GetString(key string) string {
+	value, _ := m[key].(string)
+	return value
+}
+
+func (m mockMapConfig) GetBool(key string) bool {
+	value, _ := m[key].(bool)
+	return value
+}
+
+func (m mockMapConfig) GetInt(key string) int {
+	value, _ := m[key].(int)
+	return value
+}
+
+func (m mockMapConfig) GetStringSlice(key string) []string {
+	value, _ := m[key].([]string)
+	return value
+}
+
+func (m mockMapConfig) GetDuration(key string) time.Duration {
+	value, _ := time.ParseDuration(m.GetString(key))
+	return value
+}
+
+// GetStringMap returns the values under key as nested maps, as Viper does.
+func (m mockMapConfig) GetStringMap(key string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range m {
+		if !strings.HasPrefix(k, key+".") {
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(k, key+"."), ".")
+		nested := result
+		for _, part := range parts[:len(parts)-1] {
+			if _, ok := nested[part].(map[string]interface{}); !ok {
+				nested[part] = make(map[string]interface{})
+			}
+			nested = nested[part].(map[string]interface{})
+		}
+		nested[parts[len(parts)-1]] = v
+	}
+	return result
+}
+
+func (m mockMapConfig) GetStringMapString(key string) map[string]string {
+	result := make(map[string]string)
+	for k, v := range m.GetStringMap(key) {
+		result[k], _ = v.(string)
+	}
+	return result
+}
+
+func TestLoadPolicy(t *testing.T) {
+	config := mockMapConfig{
+		"app.required":                               2,
+		"app.allowed":                                []string{"reviewer1", "reviewer2"},
+		"app.branches.required.develop":              1,
+		"app.branch_policies.release/*.required":     3,
+		"app.branch_policies.release/*.merge_method": "squash",
+		"app.branch_policies.release/*.contexts":     []string{"ci"},
+	}
+
+	policy, err := LoadPolicy(config, "app")
+	if err != nil {
+		t.Fatalf("LoadPolicy returned error(%s)", err)
+	}
+
+	testPolicy := func(branch string, expected Rules) {
+		effective := policy.For(branch)
+		if !reflect.DeepEqual(effective, expected) {
+			t.Fatalf("Bad policy %+v (expected %+v) for branch %v", effective, expected, branch)
+		}
+	}
+
+	allowed := []string{"reviewer1", "reviewer2"}
+	testPolicy("feature/login", Rules{Required: 2, Allowed: allowed, MergeMethod: "merge"})
+	testPolicy("develop", Rules{Required: 1, Allowed: allowed, MergeMethod: "merge"})
+	testPolicy("release/1.0", Rules{Required: 3, Allowed: allowed, MergeMethod: "squash", Contexts: []string{"ci"}})
+
+	table := PolicyTable("app", policy)
+	if !strings.Contains(table, "release/*") || !strings.Contains(table, "+1:3 allowed:reviewer1,reviewer2 merge:squash contexts:ci") {
+		t.Fatalf("Bad policy table:\n%s", table)
+	}
+
+	if policy.Fork != nil {
+		t.Fatal("Without fork_policy there should be no fork policy")
+	}
+
+	config["app.fork_policy"] = map[string]interface{}{}
+	config["app.fork_policy.maintainers"] = []string{"reviewer1"}
+	config["app.fork_policy.auto_merge"] = false
+	policy, err = LoadPolicy(config, "app")
+	if err != nil {
+		t.Fatalf("LoadPolicy returned error(%s)", err)
+	}
+	fork := policy.ForPullRequest(PullRequestInfo{Base: "release/1.0", Fork: true})
+	if fork.Required != 3 || !fork.NoAutoMerge || !reflect.DeepEqual(fork.Maintainers, []string{"reviewer1"}) {
+		t.Fatalf("Bad fork policy %+v", fork)
+	}
+	if policy.ForPullRequest(PullRequestInfo{Base: "release/1.0"}).NoAutoMerge {
+		t.Fatal("Fork policy shouldn't apply to pull requests not from forks")
+	}
+
+	config["app.fork_policy.required"] = 4
+	config["app.fork_policy.contexts"] = []string{"security"}
+	config["app.fork_policy.min_age"] = "1h"
+	config["app.branch_policies.release/*.min_age"] = "24h"
+	policy, err = LoadPolicy(config, "app")
+	if err != nil {
+		t.Fatalf("LoadPolicy returned error(%s)", err)
+	}
+	fork = policy.ForPullRequest(PullRequestInfo{Base: "feature/login", Fork: true})
+	if fork.Required != 4 || len(fork.Contexts) != 0 || fork.MinAge != time.Hour {
+		t.Fatalf("Bad fork policy %+v for feature/login", fork)
+	}
+	fork = policy.ForPullRequest(PullRequestInfo{Base: "release/1.0", Fork: true})
+	if fork.Required != 4 || !reflect.DeepEqual(fork.Contexts, []string{"ci", "security"}) || fork.MinAge != 24*time.Hour {
+		t.Fatalf("Bad fork policy %+v for release/1.0", fork)
+	}
+	config["app.branch_policies.release/*.required"] = 5
+	config["app.branch_policies.release/*.maintainers"] = []string{"lead"}
+	policy, err = LoadPolicy(config, "app")
+	if err != nil {
+		t.Fatalf("LoadPolicy returned error(%s)", err)
+	}
+	fork = policy.ForPullRequest(PullRequestInfo{Base: "release/1.0", Fork: true})
+	if fork.Required != 5 || !fork.NoAutoMerge || !reflect.DeepEqual(fork.Maintainers, []string{"lead", "reviewer1"}) {
+		t.Fatalf("Fork policy shouldn't be looser than the branch's %+v", fork)
+	}
+
+	config["app.authors.allow"] = []string{"MEMBER", "bob"}
+	config["app.authors.deny"] = []string{"mallory"}
+	config["app.fork_policy.allowed"] = []string{"reviewer2", "outsider"}
+	config["app.fork_policy.authors.allow"] = []string{"bob", "carol"}
+	config["app.fork_policy.authors.deny"] = []string{"FIRST_TIME_CONTRIBUTOR"}
+	policy, err = LoadPolicy(config, "app")
+	if err != nil {
+		t.Fatalf("LoadPolicy returned error(%s)", err)
+	}
+	fork = policy.ForPullRequest(PullRequestInfo{Base: "develop", Fork: true})
+	expected := AuthorRules{Allow: []string{"bob"}, Deny: []string{"mallory", "FIRST_TIME_CONTRIBUTOR"}}
+	if !reflect.DeepEqual(fork.Allowed, []string{"reviewer2"}) || !reflect.DeepEqual(fork.Authors, expected) {
+		t.Fatalf("Fork policy shouldn't allow more reviewers or authors than the branch's %+v", fork)
+	}
+
+	config["app.branch_policies.release/*.merge_method"] = "fast-forward"
+	if _, err = LoadPolicy(config, "app"); err == nil {
+		t.Fatal("An invalid merge_method should return error")
+	}
+}
+
+func TestStricterAuthors(t *testing.T) {
+	testAuthors := func(policy AuthorRules, overridden AuthorRules, expected AuthorRules) {
+		authors := stricter(Rules{Authors: policy}, Rules{Authors: overridden}).Authors
+		if !reflect.DeepEqual(authors, expected) {
+			t.Fatalf("Bad authors %+v (expected %+v) overriding %+v with %+v", authors, expected, policy, overridden)
+		}
+	}
+
+	testAuthors(AuthorRules{}, AuthorRules{}, AuthorRules{})
+	testAuthors(AuthorRules{Allow: []string{"MEMBER"}}, AuthorRules{}, AuthorRules{Allow: []string{"MEMBER"}})
+	testAuthors(AuthorRules{}, AuthorRules{Allow: []string{"bob"}}, AuthorRules{Allow: []string{"bob"}})
+	testAuthors(AuthorRules{Allow: []string{"MEMBER"}}, AuthorRules{Allow: []string{"bob"}}, AuthorRules{Allow: []string{}})
+	testAuthors(AuthorRules{Deny: []string{"mallory"}}, AuthorRules{Deny: []string{"eve"}}, AuthorRules{Deny: []string{"mallory", "eve"}})
+
+	if allowed := stricter(Rules{Allowed: []string{"reviewer1"}}, Rules{Allowed: []string{"reviewer2"}}).Allowed; len(allowed) != 0 {
+		t.Fatalf("Reviewers allowed by only one of the policies shouldn't be allowed, got %v", allowed)
+	}
+}
+
+// newYAMLConfig returns a ConfigReader with the YAML configuration, read by Viper.
+func newYAMLConfig(t *testing.T, yaml string) ConfigReader {
+	config := viper.New()
+	config.SetConfigType("yaml")
+	if err := config.ReadConfig(strings.NewReader(yaml)); err != nil {
+		t.Fatalf("Bad YAML configuration: %v", err)
+	}
+	return NewConfig(config)
+}
+
+func TestLoadPolicyDottedPatterns(t *testing.T) {
+	config := newYAMLConfig(t, `
+app:
+  required: 1
+  branches:
+    required:
+      release-1.2: 3
+      v1.*: 2
+  branch_policies:
+    release-1.*:
+      merge_method: squash
+      authors:
+        allow: [alice]
+`)
+	policy, err := LoadPolicy(config, "app")
+	if err != nil {
+		t.Fatalf("LoadPolicy returned error(%s)", err)
+	}
+	if required := policy.For("release-1.2").Required; required != 3 {
+		t.Fatalf("Bad required %v (expected 3) for branch release-1.2", required)
+	}
+	if required := policy.For("v1.0").Required; required != 2 {
+		t.Fatalf("Bad required %v (expected 2) for branch v1.0", required)
+	}
+	release := policy.For("release-1.3")
+	if release.MergeMethod != "squash" || !reflect.DeepEqual(release.Authors.Allow, []string{"alice"}) {
+		t.Fatalf("Bad policy %+v for branch release-1.3", release)
+	}
+
+	config = newYAMLConfig(t, `
+app:
+  branches:
+    required:
+      release-1.2: many
+`)
+	if _, err = LoadPolicy(config, "app"); err == nil {
+		t.Fatal("A required which isn't a number should return error")
+	}
+
+	for _, yaml := range []string{"app:\n  required: -1\n", "app:\n  branches:\n    required:\n      develop: -1\n"} {
+		if _, err = LoadPolicy(newYAMLConfig(t, yaml), "app"); err == nil {
+			t.Fatalf("A negative required should return error in:\n%s", yaml)
+		}
+	}
+}
+
+func TestApprovedByMaintainer(t *testing.T) {
+	maintainers := []string{"lead"}
+
+	if !ApprovedByMaintainer(nil, nil) {
+		t.Fatal("Without maintainers it should be approved")
+	}
+	if ApprovedByMaintainer([]Vote{{Login: "reviewer1", Score: 1}}, maintainers) {
+		t.Fatal("Without maintainer votes it shouldn't be approved")
+	}
+	if ApprovedByMaintainer([]Vote{{Login: "lead", Score: 1, Reason: VoteNotAllowed}}, maintainers) {
+		t.Fatal("Ignored maintainer votes shouldn't approve")
+	}
+	if !ApprovedByMaintainer([]Vote{{Login: "lead", Score: 1}}, maintainers) {
+		t.Fatal("A maintainer vote should approve")
+	}
+}
+
+func TestWithinLimit(t *testing.T) {
+	if !WithinLimit(0, 100) {
+		t.Fatal("Without maximum every merge should be allowed")
+	}
+	if !WithinLimit(2, 1) {
+		t.Fatal("Below the maximum merges should be allowed")
+	}
+	if WithinLimit(2, 2) {
+		t.Fatal("Reaching the maximum merges shouldn't be allowed")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// githubURL is where the links of the reports point to.
+const githubURL = "https://github.com"
+
+// reportRepository is a repository with its pull requests, as shown in the rendered reports.
+type reportRepository struct {
+	Owner        string
+	Repo         string
+	Discarded    string
+	PullRequests []Result
+}
+
+// URL returns the address of the repository.
+func (r reportRepository) URL() string {
+	return fmt.Sprintf("%v/%v/%v", githubURL, r.Owner, r.Repo)
+}
+
+// report is what the rendered reports show.
+type report struct {
+	Generated    time.Time
+	Repositories []*reportRepository
+}
+
+// scoreBar returns a bar with a filled block per point of the score, up to the required one.
+func scoreBar(score int, required int) string {
+	if required < 0 {
+		required = 0
+	}
+	filled := score
+	if filled > required {
+		filled = required
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	return strings.Repeat("■", filled) + strings.Repeat("□", required-filled)
+}
+
+// badgeColor returns the color of the badge for the tests state or decision.
+func badgeColor(state string) string {
+	switch state {
+	case "success", ActionMerge:
+		return "brightgreen"
+	case "pending", ActionHold, ActionDeferred:
+		return "yellow"
+	case "failure", "error":
+		return "red"
+	}
+	return "lightgrey"
+}
+
+// badgeURL returns the address of a static badge image with the label and message.
+func badgeURL(label string, message string) string {
+	if message == "" {
+		message = "unknown"
+	}
+	escape := strings.NewReplacer("-", "--", "_", "__", " ", "_")
+	return fmt.Sprintf("https://img.shields.io/badge/%v-%v-%v", escape.Replace(label), escape.Replace(message), badgeColor(message))
+}
+
+// markdownEscape escapes the text to be shown within a markdown table.
+func markdownEscape(text string) string {
+	return strings.NewReplacer("|", `\|`, "[", `\[`, "]", `\]`, "\n", " ").Replace(text)
+}
+
+var reportFuncs = map[string]interface{}{
+	"bar":   scoreBar,
+	"badge": badgeURL,
+	"color": badgeColor,
+	"md":    markdownEscape,
+}
+
+var markdownReport = template.Must(template.New("markdown").Funcs(reportFuncs).Parse(
+	`# Reviewer report
+
+Generated on {{.Generated.Format "2006-01-02 15:04 MST"}}.
+{{range .Repositories}}
+## [{{.Owner}}/{{.Repo}}]({{.URL}})
+{{if .Discarded}}
+Discarded: {{md .Discarded}}
+{{else if not .PullRequests}}
+No pull requests pending.
+{{else}}{{$repo := .}}
+| Pull request | Author | Score | Tests | Decision | Reason |
+|--------------|--------|-------|-------|----------|--------|
+{{range .PullRequests}}| [#{{.Number}}]({{$repo.URL}}/pull/{{.Number}}) {{md .Title}} | {{.Author}} | {{bar .Score .Rules.Required}} {{.Score}}/{{.Rules.Required}} | ![tests]({{badge "tests" .TestsState}}) | ![decision]({{badge "decision" .Action}}) | {{md .Reason}} |
+{{end}}{{end}}{{end}}`))
+
+var htmlReport = htmltemplate.Must(htmltemplate.New("html").Funcs(reportFuncs).Parse(
+	`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Reviewer report</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; }
+.badge { border-radius: 3px; color: white; padding: 1px 6px; font-size: smaller; }
+.brightgreen { background: #4c1; }
+.yellow { background: #dfb317; }
+.red { background: #e05d44; }
+.lightgrey { background: #9f9f9f; }
+</style>
+</head>
+<body>
+<h1>Reviewer report</h1>
+<p>Generated on {{.Generated.Format "2006-01-02 15:04 MST"}}.</p>
+{{range .Repositories}}<h2><a href="{{.URL}}">{{.Owner}}/{{.Repo}}</a></h2>
+{{if .Discarded}}<p>Discarded: {{.Discarded}}</p>
+{{else if not .PullRequests}}<p>No pull requests pending.</p>
+{{else}}{{$repo := .}}<table>
+<tr><th>Pull request</th><th>Author</th><th>Score</th><th>Tests</th><th>Decision</th><th>Reason</th></tr>
+{{range .PullRequests}}<tr><td><a href="{{$repo.URL}}/pull/{{.Number}}">#{{.Number}}</a> {{.Title}}</td><td>{{.Author}}</td><td>{{bar .Score .Rules.Required}} {{.Score}}/{{.Rules.Required}}</td><td><span class="badge {{color .TestsState}}">{{or .TestsState "unknown"}}</span></td><td><span class="badge {{color .Action}}">{{.Action}}</span></td><td>{{.Reason}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// renderer is the template of a rendered report.
+type renderer interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// renderReporter collects the repositories and pull requests, and renders them with its template when closed.
+type renderReporter struct {
+	w        io.Writer
+	template renderer
+	report   report
+}
+
+func (r *renderReporter) Repository(owner string, repo string, discarded string) {
+	r.report.Repositories = append(r.report.Repositories, &reportRepository{Owner: owner, Repo: repo, Discarded: discarded})
+}
+
+func (r *renderReporter) PullRequest(result Result) {
+	repositories := r.report.Repositories
+	owner, repo := result.Repository.Owner, result.Repository.Name
+	if len(repositories) == 0 || repositories[len(repositories)-1].Owner != owner || repositories[len(repositories)-1].Repo != repo {
+		r.Repository(owner, repo, "")
+	}
+	last := r.report.Repositories[len(r.report.Repositories)-1]
+	last.PullRequests = append(last.PullRequests, result)
+}
+
+func (r *renderReporter) Close() error {
+	r.report.Generated = Now()
+	return r.template.Execute(r.w, r.report)
+}
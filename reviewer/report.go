@@ -0,0 +1,225 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Output formats of the run reports.
+const (
+	OutputText     = "text"
+	OutputJSON     = "json"
+	OutputJSONL    = "jsonl"
+	OutputMarkdown = "markdown"
+	OutputHTML     = "html"
+	OutputJUnit    = "junit"
+)
+
+// DiscardedDisabled is the reason repositories disabled in the configuration are discarded for.
+const DiscardedDisabled = "repo disabled"
+
+// Result is the outcome of applying a decision.
+type Result struct {
+	Decision
+	Merged   bool   // false in dry-run mode
+	MergeSHA string // SHA of the merge commit, when merged
+	MergeErr error  // merge failure, while Decision.Err is the failure evaluating it
+}
+
+// Reporter reports the repositories and pull requests processed in a run.
+type Reporter interface {
+	// Repository reports a repository, discarded for the reason when it's not empty.
+	Repository(owner string, repo string, discarded string)
+	// PullRequest reports a processed pull request.
+	PullRequest(result Result)
+	// Close finishes the report.
+	Close() error
+}
+
+// CheckOutput returns an error if the output format isn't known.
+func CheckOutput(format string) error {
+	switch format {
+	case "", OutputText, OutputJSON, OutputJSONL, OutputMarkdown, OutputHTML, OutputJUnit:
+		return nil
+	}
+	return fmt.Errorf("Unknown output format %q", format)
+}
+
+// NewReporter returns a reporter writing in the output format to w.
+func NewReporter(format string, w io.Writer) (Reporter, error) {
+	switch format {
+	case "", OutputText:
+		return &textReporter{w: w}, nil
+	case OutputJSON:
+		return &jsonReporter{w: w, records: []interface{}{}}, nil
+	case OutputJSONL:
+		return &jsonReporter{w: w, stream: true}, nil
+	case OutputMarkdown:
+		return &renderReporter{w: w, template: markdownReport}, nil
+	case OutputHTML:
+		return &renderReporter{w: w, template: htmlReport}, nil
+	case OutputJUnit:
+		return &renderReporter{w: w, template: junitRenderer{}}, nil
+	}
+	return nil, CheckOutput(format)
+}
+
+// textReporter writes the human readable lines printed by Execute.
+type textReporter struct {
+	w io.Writer
+}
+
+func (r *textReporter) Repository(owner string, repo string, discarded string) {
+	if discarded != "" {
+		fmt.Fprintf(r.w, "- %v/%v Discarded (%v)\n", owner, repo, discarded)
+		return
+	}
+	fmt.Fprintf(r.w, "+ %v/%v\n", owner, repo)
+}
+
+func (r *textReporter) PullRequest(result Result) {
+	switch {
+	case result.Action != ActionMerge:
+		fmt.Fprintf(r.w, "%v\n", result.Decision)
+	case result.MergeErr != nil:
+		fmt.Fprintf(r.w, "  + %v -merge- %v  Merge failed: %v\n", result.Number, result.title(), result.MergeErr)
+	case result.Merged:
+		fmt.Fprintf(r.w, "  + %v MERGE %v %v\n", result.Number, result.title(), result.Reason)
+	default:
+		fmt.Fprintf(r.w, "  - %v (merge)  %v %v\n", result.Number, result.title(), result.Reason)
+	}
+}
+
+func (r *textReporter) Close() error {
+	return nil
+}
+
+// RepositoryRecord is the machine-readable report of a repository.
+type RepositoryRecord struct {
+	Type   string `json:"type"` // always "repository"
+	Owner  string `json:"owner"`
+	Repo   string `json:"repo"`
+	Status string `json:"status"`           // "processed" or "discarded"
+	Reason string `json:"reason,omitempty"` // why it was discarded
+}
+
+// VoteRecord is the machine-readable report of a vote.
+type VoteRecord struct {
+	Login   string `json:"login"`
+	Score   int    `json:"score"`
+	Ignored string `json:"ignored,omitempty"` // why it wasn't counted
+}
+
+// PullRequestRecord is the machine-readable report of a pull request.
+type PullRequestRecord struct {
+	Type       string       `json:"type"` // always "pull_request"
+	Owner      string       `json:"owner"`
+	Repo       string       `json:"repo"`
+	Number     int          `json:"number"`
+	Title      string       `json:"title"`
+	Author     string       `json:"author"`
+	Base       string       `json:"base"`
+	Fork       bool         `json:"fork"`
+	Score      int          `json:"score"`
+	Required   int          `json:"required"`
+	Votes      []VoteRecord `json:"votes"`
+	Mergeable  *bool        `json:"mergeable"` // null when unknown
+	Tests      string       `json:"tests"`
+	Decision   string       `json:"decision"`
+	ReasonCode string       `json:"reason_code"`
+	Reason     string       `json:"reason"`
+	Merged     bool         `json:"merged"`
+	MergeSHA   string       `json:"merge_sha,omitempty"`
+	Error      string       `json:"error,omitempty"` // merge or evaluation failure
+}
+
+// NewPullRequestRecord returns the machine-readable report of the result.
+func NewPullRequestRecord(result Result) PullRequestRecord {
+	record := PullRequestRecord{
+		Type:       "pull_request",
+		Owner:      result.Repository.Owner,
+		Repo:       result.Repository.Name,
+		Number:     result.Number,
+		Title:      result.Title,
+		Author:     result.Author,
+		Base:       result.Base,
+		Fork:       result.Fork,
+		Score:      result.Score,
+		Required:   result.Rules.Required,
+		Votes:      []VoteRecord{},
+		Mergeable:  result.Mergeable,
+		Tests:      result.TestsState,
+		Decision:   result.Action,
+		ReasonCode: result.Code,
+		Reason:     result.Reason,
+		Merged:     result.Merged,
+		MergeSHA:   result.MergeSHA,
+	}
+	for _, vote := range result.Votes {
+		record.Votes = append(record.Votes, VoteRecord{Login: vote.Login, Score: vote.Score, Ignored: vote.Reason})
+	}
+	if result.MergeErr != nil {
+		record.Error = result.MergeErr.Error()
+	} else if result.Err != nil {
+		record.Error = result.Err.Error()
+	}
+	return record
+}
+
+// jsonReporter writes the records as a JSON array when closed, or one per line as they come when streaming.
+type jsonReporter struct {
+	w       io.Writer
+	stream  bool
+	records []interface{}
+	err     error
+}
+
+func (r *jsonReporter) add(record interface{}) {
+	if !r.stream {
+		r.records = append(r.records, record)
+		return
+	}
+	if r.err == nil {
+		r.err = json.NewEncoder(r.w).Encode(record)
+	}
+}
+
+func (r *jsonReporter) Repository(owner string, repo string, discarded string) {
+	record := RepositoryRecord{Type: "repository", Owner: owner, Repo: repo, Status: "processed"}
+	if discarded != "" {
+		record.Status = "discarded"
+		record.Reason = discarded
+	}
+	r.add(record)
+}
+
+func (r *jsonReporter) PullRequest(result Result) {
+	r.add(NewPullRequestRecord(result))
+}
+
+func (r *jsonReporter) Close() error {
+	if r.stream || r.err != nil {
+		return r.err
+	}
+	output, err := json.MarshalIndent(r.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(r.w, "%s\n", output)
+	return err
+}
@@ -0,0 +1,262 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func reportResults() []Result {
+	mergeable := true
+	return []Result{
+		{
+			Decision: Decision{
+				Repository: Repository{Owner: "cooldeveloper", Name: "mycoolapp"},
+				PullRequestInfo: PullRequestInfo{Number: 47, Title: "Changes CI badge", Author: "bob", Score: 1,
+					Votes: []Vote{{Login: "alice", Score: 1}, {Login: "bob", Score: 1, Reason: VoteSelf}}},
+				Rules:      Rules{Required: 3},
+				Action:     ActionNOP,
+				Reason:     "score 1 of 3 required",
+				Code:       ReasonScore,
+				Mergeable:  &mergeable,
+				TestsState: "success",
+			},
+		},
+		{
+			Decision: Decision{
+				Repository:      Repository{Owner: "cooldeveloper", Name: "mycoolapp"},
+				PullRequestInfo: PullRequestInfo{Number: 48, Title: "Fixes typo", Score: 3},
+				Rules:           Rules{Required: 3},
+				Action:          ActionMerge,
+				Reason:          "score 3 of 3 required",
+				Code:            ReasonApproved,
+			},
+			Merged:   true,
+			MergeSHA: "abc123",
+		},
+		{
+			Decision: Decision{
+				Repository:      Repository{Owner: "cooldeveloper", Name: "mycoolapp"},
+				PullRequestInfo: PullRequestInfo{Number: 49, Title: "Adds tests", Score: 3},
+				Rules:           Rules{Required: 3},
+				Action:          ActionMerge,
+				Reason:          "score 3 of 3 required",
+				Code:            ReasonApproved,
+			},
+			MergeErr: errors.New("conflict"),
+		},
+	}
+}
+
+func writeReport(t *testing.T, format string) string {
+	var output bytes.Buffer
+	reporter, err := NewReporter(format, &output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter.Repository("cooldeveloper", "mycoolapi", "repo disabled")
+	reporter.Repository("cooldeveloper", "mycoolapp", "")
+	for _, result := range reportResults() {
+		reporter.PullRequest(result)
+	}
+	if err := reporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return output.String()
+}
+
+func TestTextReporter(t *testing.T) {
+	expected := `- cooldeveloper/mycoolapi Discarded (repo disabled)
++ cooldeveloper/mycoolapp
+  - 47 NOP   (Changes CI badge) score 1 of 3 required
+  + 48 MERGE (Fixes typo) score 3 of 3 required
+  + 49 -merge- (Adds tests)  Merge failed: conflict
+`
+	if output := writeReport(t, OutputText); output != expected {
+		t.Fatalf("Bad text report:\n%v\nexpected:\n%v", output, expected)
+	}
+}
+
+func TestJSONReporter(t *testing.T) {
+	var records []map[string]interface{}
+	if err := json.Unmarshal([]byte(writeReport(t, OutputJSON)), &records); err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, records)
+}
+
+func TestJSONLReporter(t *testing.T) {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(writeReport(t, OutputJSONL)), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	checkRecords(t, records)
+}
+
+func checkRecords(t *testing.T, records []map[string]interface{}) {
+	if len(records) != 5 {
+		t.Fatalf("Expected 5 records, got %v", len(records))
+	}
+	if records[0]["type"] != "repository" || records[0]["status"] != "discarded" || records[0]["reason"] != "repo disabled" {
+		t.Errorf("Bad discarded repository record %v", records[0])
+	}
+	if records[1]["type"] != "repository" || records[1]["status"] != "processed" {
+		t.Errorf("Bad repository record %v", records[1])
+	}
+	nop := records[2]
+	if nop["type"] != "pull_request" || nop["number"] != 47.0 || nop["decision"] != "NOP" || nop["reason_code"] != "score" ||
+		nop["required"] != 3.0 || nop["mergeable"] != true || nop["tests"] != "success" || nop["author"] != "bob" {
+		t.Errorf("Bad pull request record %v", nop)
+	}
+	votes := nop["votes"].([]interface{})
+	if len(votes) != 2 || votes[1].(map[string]interface{})["ignored"] != VoteSelf {
+		t.Errorf("Bad votes %v", votes)
+	}
+	if merged := records[3]; merged["merged"] != true || merged["merge_sha"] != "abc123" || merged["mergeable"] != nil {
+		t.Errorf("Bad merged pull request record %v", merged)
+	}
+	if failed := records[4]; failed["merged"] != false || failed["error"] != "conflict" {
+		t.Errorf("Bad failed pull request record %v", failed)
+	}
+}
+
+func TestCheckOutput(t *testing.T) {
+	for _, format := range []string{"", "text", "json", "jsonl"} {
+		if err := CheckOutput(format); err != nil {
+			t.Errorf("Output %q should be valid: %v", format, err)
+		}
+	}
+	if err := CheckOutput("xml"); err == nil {
+		t.Error("Output xml shouldn't be valid")
+	}
+}
+
+func TestMarkdownReporter(t *testing.T) {
+	output := writeReport(t, OutputMarkdown)
+	for _, expected := range []string{
+		"## [cooldeveloper/mycoolapi](https://github.com/cooldeveloper/mycoolapi)\n\nDiscarded: repo disabled\n",
+		"## [cooldeveloper/mycoolapp](https://github.com/cooldeveloper/mycoolapp)\n",
+		"| [#47](https://github.com/cooldeveloper/mycoolapp/pull/47) Changes CI badge | bob | ■□□ 1/3 | ![tests](https://img.shields.io/badge/tests-success-brightgreen) | ![decision](https://img.shields.io/badge/decision-NOP-lightgrey) | score 1 of 3 required |\n",
+		"| [#48](https://github.com/cooldeveloper/mycoolapp/pull/48) Fixes typo |  | ■■■ 3/3 |",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Markdown report should contain %q:\n%v", expected, output)
+		}
+	}
+}
+
+func TestHTMLReporter(t *testing.T) {
+	var output bytes.Buffer
+	reporter, _ := NewReporter(OutputHTML, &output)
+	result := reportResults()[0]
+	result.Title = "<script>alert(1)</script>"
+	reporter.PullRequest(result)
+	if err := reporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<h2><a href="https://github.com/cooldeveloper/mycoolapp">cooldeveloper/mycoolapp</a></h2>`,
+		`<a href="https://github.com/cooldeveloper/mycoolapp/pull/47">#47</a> &lt;script&gt;`,
+		`<span class="badge brightgreen">success</span>`,
+		`<td>score 1 of 3 required</td>`,
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("HTML report should contain %q:\n%v", expected, output.String())
+		}
+	}
+}
+
+func TestScoreBar(t *testing.T) {
+	tests := []struct {
+		score    int
+		required int
+		expected string
+	}{
+		{0, 2, "□□"},
+		{1, 3, "■□□"},
+		{4, 3, "■■■"},
+		{-1, 2, "□□"},
+		{1, -1, ""},
+	}
+	for _, test := range tests {
+		if bar := scoreBar(test.score, test.required); bar != test.expected {
+			t.Errorf("Bar for %v of %v should be %q, got %q", test.score, test.required, test.expected, bar)
+		}
+	}
+}
+
+func TestJUnitReporter(t *testing.T) {
+	output := writeReport(t, OutputJUnit)
+	for _, expected := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<testsuite name="cooldeveloper/mycoolapi" tests="1" failures="0" errors="0" skipped="1">`,
+		`<testcase name="repository" classname="cooldeveloper/mycoolapi">`,
+		`<skipped></skipped>`,
+		`<testsuite name="cooldeveloper/mycoolapp" tests="3" failures="1" errors="1" skipped="0">`,
+		`<testcase name="#47 Changes CI badge" classname="cooldeveloper/mycoolapp">`,
+		`<failure message="score 1 of 3 required" type="score">NOP score 1 of 3 required</failure>`,
+		`<testcase name="#48 Fixes typo" classname="cooldeveloper/mycoolapp"></testcase>`,
+		`<error message="Merge failed: conflict" type="merge"></error>`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("JUnit report should contain %q:\n%v", expected, output)
+		}
+	}
+
+	var discarded bytes.Buffer
+	reporter, _ := NewReporter(OutputJUnit, &discarded)
+	reporter.Repository("cooldeveloper", "mycoolapi", "error getting pull request info: Not Found")
+	if err := reporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `<error message="Discarded: error getting pull request info: Not Found" type="discarded"></error>`
+	if !strings.Contains(discarded.String(), expected) {
+		t.Errorf("JUnit report should contain %q:\n%v", expected, discarded.String())
+	}
+}
+
+func TestReportEvaluationError(t *testing.T) {
+	result := Result{Decision: Decision{
+		Repository:      Repository{Owner: "cooldeveloper", Name: "mycoolapp"},
+		PullRequestInfo: PullRequestInfo{Number: 50, Title: "Adds labels"},
+		Action:          ActionNOP,
+		Reason:          "Failure getting labels",
+		Code:            ReasonError,
+		Err:             errors.New("Not Found"),
+	}}
+	for format, expected := range map[string]string{
+		OutputJSONL: `"error":"Not Found"`,
+		OutputJUnit: `<error message="Failure getting labels" type="error">Not Found</error>`,
+	} {
+		var output bytes.Buffer
+		reporter, _ := NewReporter(format, &output)
+		reporter.Repository("cooldeveloper", "mycoolapp", "")
+		reporter.PullRequest(result)
+		if err := reporter.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%v report should contain %q:\n%v", format, expected, output.String())
+		}
+	}
+}
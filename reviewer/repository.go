@@ -0,0 +1,76 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"regexp"
+)
+
+// Repository contains the settings of a repository in the configuration file.
+type Repository struct {
+	Name          string
+	Owner         string
+	Enabled       bool
+	Comment       bool              // whether to keep a summary comment in each pull request
+	Labels        map[string]string // labels applied for each review state
+	SkipLabels    []string
+	RequireLabels []string
+	Filter        Filter
+	Policy        RepoPolicy
+}
+
+// LoadRepository returns the settings of the repository, using the global aliases, and the global schedule unless it has its own.
+// Disabled repositories are returned without loading the rest of their settings.
+func LoadRepository(config ConfigReader, repoName string, aliases [][]string, schedule *Schedule) (Repository, error) {
+	repository := Repository{
+		Name:    repoName,
+		Owner:   config.GetString(repoName + ".username"),
+		Enabled: config.GetBool(repoName + ".status"),
+	}
+	if !repository.Enabled {
+		return repository, nil
+	}
+	repository.Comment = config.GetBool(repoName + ".comment")
+	repository.Labels = config.GetStringMapString(repoName + ".labels")
+	repository.SkipLabels = config.GetStringSlice(repoName + ".skip_labels")
+	repository.RequireLabels = config.GetStringSlice(repoName + ".require_labels")
+
+	wipPattern := DefaultWIPPattern
+	if GetString("wip_pattern") != "" {
+		wipPattern = GetString("wip_pattern")
+	}
+	if config.GetString(repoName+".wip_pattern") != "" {
+		wipPattern = config.GetString(repoName + ".wip_pattern")
+	}
+	wip, err := regexp.Compile(wipPattern)
+	if err != nil {
+		return repository, err
+	}
+	repository.Filter = Filter{
+		WIP:     wip,
+		Include: config.GetStringSlice(repoName + ".branches.include"),
+		Exclude: config.GetStringSlice(repoName + ".branches.exclude"),
+	}
+
+	repository.Policy, err = LoadPolicy(config, repoName)
+	if err != nil {
+		return repository, err
+	}
+	repository.Policy.Aliases = aliases
+	if repository.Policy.Schedule == nil {
+		repository.Policy.Schedule = schedule
+	}
+	return repository, nil
+}
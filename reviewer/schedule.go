@@ -0,0 +1,266 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window is a weekly period when merges are allowed, e.g. "Mon-Fri 09:00-17:00".
+type Window struct {
+	Days [7]bool // indexed by time.Weekday
+	From int     // minutes since midnight
+	To   int     // minutes since midnight, exclusive
+}
+
+// Freeze is a period when merges are not allowed.
+type Freeze struct {
+	From time.Time
+	To   time.Time // exclusive
+}
+
+// Schedule contains when merges are allowed: inside any of the windows, or always if there are none, but never during freezes.
+type Schedule struct {
+	Location *time.Location
+	Windows  []Window
+	Freezes  []Freeze
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseWeekday returns the weekday from its three letters name.
+func parseWeekday(name string) (time.Weekday, error) {
+	day, exists := weekdays[strings.ToLower(strings.TrimSpace(name))]
+	if !exists {
+		return day, fmt.Errorf("Invalid weekday %q", name)
+	}
+	return day, nil
+}
+
+// parseClock returns the minutes since midnight of a HH:MM time.
+func parseClock(clock string) (int, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("Invalid time %q", clock)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("Invalid time %q", clock)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || hours < 0 || minutes < 0 || minutes > 59 || hours*60+minutes > 24*60 {
+		return 0, fmt.Errorf("Invalid time %q", clock)
+	}
+	return hours*60 + minutes, nil
+}
+
+// ParseWindow returns the window from its definition: days as a comma separated list of names or ranges, and an optional time range,
+// e.g. "Mon-Thu 09:00-18:00" or "Fri,Sat".
+func ParseWindow(definition string) (Window, error) {
+	var window Window
+	fields := strings.Fields(definition)
+	if len(fields) < 1 || len(fields) > 2 {
+		return window, fmt.Errorf("Invalid window %q", definition)
+	}
+	for _, days := range strings.Split(fields[0], ",") {
+		limits := strings.SplitN(days, "-", 2)
+		first, err := parseWeekday(limits[0])
+		if err != nil {
+			return window, err
+		}
+		last := first
+		if len(limits) == 2 {
+			if last, err = parseWeekday(limits[1]); err != nil {
+				return window, err
+			}
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			window.Days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	window.To = 24 * 60
+	if len(fields) == 2 {
+		limits := strings.SplitN(fields[1], "-", 2)
+		if len(limits) != 2 {
+			return window, fmt.Errorf("Invalid window %q", definition)
+		}
+		var err error
+		if window.From, err = parseClock(limits[0]); err != nil {
+			return window, err
+		}
+		if window.To, err = parseClock(limits[1]); err != nil {
+			return window, err
+		}
+		if window.From >= window.To {
+			return window, fmt.Errorf("Invalid window %q", definition)
+		}
+	}
+	return window, nil
+}
+
+// parseMoment returns the time, given as a date or a date and time, in the location.
+// Dates alone mean the start of the day, or the start of the next one when end is true.
+func parseMoment(moment string, location *time.Location, end bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02T15:04", moment, location); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", moment, location)
+	if err != nil {
+		return t, fmt.Errorf("Invalid date %q", moment)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// ParseFreeze returns the freeze from its definition: two dates or date and times separated by "..",
+// e.g. "2016-12-20..2017-01-07" or "2016-03-01T16:00..2016-03-02T09:00". Dates alone include the whole day.
+func ParseFreeze(definition string, location *time.Location) (Freeze, error) {
+	var freeze Freeze
+	limits := strings.SplitN(definition, "..", 2)
+	if len(limits) != 2 {
+		return freeze, fmt.Errorf("Invalid freeze %q", definition)
+	}
+	var err error
+	if freeze.From, err = parseMoment(strings.TrimSpace(limits[0]), location, false); err != nil {
+		return freeze, err
+	}
+	if freeze.To, err = parseMoment(strings.TrimSpace(limits[1]), location, true); err != nil {
+		return freeze, err
+	}
+	if !freeze.From.Before(freeze.To) {
+		return freeze, fmt.Errorf("Invalid freeze %q", definition)
+	}
+	return freeze, nil
+}
+
+// LoadSchedule returns the schedule under prefix, or nil if there's none.
+func LoadSchedule(config ConfigReader, prefix string) (*Schedule, error) {
+	if !config.IsSet(prefix) {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(config.GetString(prefix + ".timezone"))
+	if err != nil {
+		return nil, err
+	}
+	schedule := &Schedule{Location: location}
+	for _, definition := range config.GetStringSlice(prefix + ".windows") {
+		window, err := ParseWindow(definition)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Windows = append(schedule.Windows, window)
+	}
+	for _, definition := range config.GetStringSlice(prefix + ".freezes") {
+		freeze, err := ParseFreeze(definition, location)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Freezes = append(schedule.Freezes, freeze)
+	}
+	return schedule, nil
+}
+
+// frozen returns the end of the freeze the time is in, or zero time if it isn't in any.
+func (s *Schedule) frozen(t time.Time) time.Time {
+	for _, freeze := range s.Freezes {
+		if !t.Before(freeze.From) && t.Before(freeze.To) {
+			return freeze.To
+		}
+	}
+	return time.Time{}
+}
+
+// inWindow returns true if there are no windows, or the time is inside any of them.
+func (s *Schedule) inWindow(t time.Time) bool {
+	if len(s.Windows) == 0 {
+		return true
+	}
+	t = t.In(s.Location)
+	minutes := t.Hour()*60 + t.Minute()
+	for _, window := range s.Windows {
+		if window.Days[t.Weekday()] && minutes >= window.From && minutes < window.To {
+			return true
+		}
+	}
+	return false
+}
+
+// nextWindow returns the earliest time, from t on, inside any of the windows, t itself if there are none,
+// or zero time if the windows have no days.
+func (s *Schedule) nextWindow(t time.Time) time.Time {
+	if len(s.Windows) == 0 {
+		return t
+	}
+	t = t.In(s.Location)
+	for days := 0; days <= 7; days++ {
+		var next time.Time
+		for _, window := range s.Windows {
+			if !window.Days[t.AddDate(0, 0, days).Weekday()] {
+				continue
+			}
+			start := time.Date(t.Year(), t.Month(), t.Day()+days, 0, window.From, 0, 0, s.Location)
+			end := time.Date(t.Year(), t.Month(), t.Day()+days, 0, window.To, 0, 0, s.Location)
+			if !end.After(t) {
+				continue
+			}
+			if start.Before(t) {
+				start = t
+			}
+			if next.IsZero() || start.Before(next) {
+				next = start
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// Hold returns whether merges must be held at the time, and until when, zero time if they're allowed again in more than a year.
+func (s *Schedule) Hold(now time.Time) (bool, time.Time) {
+	if s == nil || (s.frozen(now).IsZero() && s.inWindow(now)) {
+		return false, time.Time{}
+	}
+	limit := now.AddDate(1, 0, 0)
+	t := now
+	for {
+		t = s.nextWindow(t)
+		if t.IsZero() || !t.Before(limit) {
+			return true, time.Time{}
+		}
+		end := s.frozen(t)
+		if end.IsZero() {
+			return true, t
+		}
+		t = end
+	}
+}
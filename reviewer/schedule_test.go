@@ -0,0 +1,101 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	window, err := ParseWindow("Fri-Mon 09:00-16:00")
+	if err != nil {
+		t.Fatalf("ParseWindow returned error(%s)", err)
+	}
+	expected := [7]bool{true, true, false, false, false, true, true}
+	if window.Days != expected || window.From != 9*60 || window.To != 16*60 {
+		t.Fatalf("Bad window %+v", window)
+	}
+
+	window, err = ParseWindow("tue,Thu")
+	if err != nil {
+		t.Fatalf("ParseWindow returned error(%s)", err)
+	}
+	expected = [7]bool{false, false, true, false, true, false, false}
+	if window.Days != expected || window.From != 0 || window.To != 24*60 {
+		t.Fatalf("Bad window %+v", window)
+	}
+
+	for _, definition := range []string{"", "Someday", "Mon 9-17", "Mon 17:00-09:00", "Mon 09:00-25:00"} {
+		if _, err = ParseWindow(definition); err == nil {
+			t.Fatalf("Invalid window %q should return error", definition)
+		}
+	}
+}
+
+func TestParseFreeze(t *testing.T) {
+	freeze, err := ParseFreeze("2016-12-20..2017-01-07", time.UTC)
+	if err != nil {
+		t.Fatalf("ParseFreeze returned error(%s)", err)
+	}
+	if !freeze.From.Equal(time.Date(2016, 12, 20, 0, 0, 0, 0, time.UTC)) || !freeze.To.Equal(time.Date(2017, 1, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Bad freeze %+v", freeze)
+	}
+
+	for _, definition := range []string{"2016-12-20", "2017-01-07..2016-12-20", "tomorrow..2016-12-20"} {
+		if _, err = ParseFreeze(definition, time.UTC); err == nil {
+			t.Fatalf("Invalid freeze %q should return error", definition)
+		}
+	}
+}
+
+func TestScheduleHold(t *testing.T) {
+	mondayToThursday, _ := ParseWindow("Mon-Thu 09:00-18:00")
+	friday, _ := ParseWindow("Fri 09:00-16:00")
+	freeze, _ := ParseFreeze("2016-01-26..2016-01-27", time.UTC)
+	schedule := &Schedule{
+		Location: time.UTC,
+		Windows:  []Window{mondayToThursday, friday},
+		Freezes:  []Freeze{freeze},
+	}
+
+	testHold := func(now time.Time, expected time.Time) {
+		hold, until := schedule.Hold(now)
+		if hold != !expected.IsZero() || !until.Equal(expected) {
+			t.Fatalf("At %v hold %v until %v (expected until %v)", now, hold, until, expected)
+		}
+	}
+
+	// Friday, January 22nd 2016
+	testHold(time.Date(2016, 1, 22, 10, 0, 0, 0, time.UTC), time.Time{})
+	testHold(time.Date(2016, 1, 22, 16, 30, 0, 0, time.UTC), time.Date(2016, 1, 25, 9, 0, 0, 0, time.UTC))
+	testHold(time.Date(2016, 1, 26, 10, 0, 0, 0, time.UTC), time.Date(2016, 1, 28, 9, 0, 0, 0, time.UTC))
+	testHold(time.Date(2016, 1, 23, 12, 30, 15, 0, time.UTC), time.Date(2016, 1, 25, 9, 0, 0, 0, time.UTC))
+
+	afternoon, _ := ParseFreeze("2016-02-01T12:00..2016-02-01T15:30", time.UTC)
+	schedule.Freezes = []Freeze{afternoon}
+	testHold(time.Date(2016, 2, 1, 13, 0, 0, 0, time.UTC), time.Date(2016, 2, 1, 15, 30, 0, 0, time.UTC))
+
+	longer, _ := ParseFreeze("2016-01-01..2017-06-01", time.UTC)
+	schedule.Freezes = []Freeze{longer}
+	if hold, until := schedule.Hold(time.Date(2016, 2, 1, 13, 0, 0, 0, time.UTC)); !hold || !until.IsZero() {
+		t.Fatalf("During a freeze longer than a year merges should be held with no end, got %v until %v", hold, until)
+	}
+
+	var none *Schedule
+	if hold, _ := none.Hold(time.Now()); hold {
+		t.Fatal("Without schedule merges shouldn't be held")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ReadInConfig contains the function used to read the configuration file again.
+var ReadInConfig = viper.ReadInConfig
+
+// jitter returns the interval randomly changed up to a tenth, so several instances don't hit GitHub at once.
+func jitter(interval time.Duration) time.Duration {
+	if interval < 10 {
+		return interval
+	}
+	return interval - interval/10 + time.Duration(rand.Int63n(int64(interval/5)))
+}
+
+// configChanged returns the configuration file's modification time, and whether it changed since the given one.
+func configChanged(modTime time.Time) (time.Time, bool) {
+	info, err := os.Stat(ConfigFileUsed())
+	if err != nil {
+		return modTime, false
+	}
+	return info.ModTime(), !info.ModTime().Equal(modTime)
+}
+
+// StopOnSignal returns a channel closed on the first SIGINT or SIGTERM, to stop between pull requests,
+// and calls cancel on the second one, to abort the GitHub calls in progress.
+func StopOnSignal(cancel context.CancelFunc) <-chan struct{} {
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		Log.Infof("Got %v, stopping after the pull request being processed", sig)
+		close(stop)
+		sig = <-signals
+		Log.Warnf("Got %v again, aborting", sig)
+		cancel()
+	}()
+	return stop
+}
+
+// Serve keeps evaluating the configured repositories every interval, reloading the configuration file when it changes,
+// until it gets SIGINT or SIGTERM, or the context is done. On a signal it stops after finishing the pull request
+// being processed, see StopOnSignal.
+// Failures are printed and retried in the next evaluation, except authentication ones, which are returned.
+func Serve(ctx context.Context, options Options, interval time.Duration) error {
+	if options.DryRun {
+		Log.Infof("Working in dry-run mode")
+	}
+	repositories, aliases, schedule, client, err := loadConfiguration()
+	if err != nil {
+		return err
+	}
+	modTime, _ := configChanged(time.Time{})
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := StopOnSignal(cancel)
+	options.Stop = stop
+
+	var changed bool
+	for {
+		if modTime, changed = configChanged(modTime); changed {
+			Log.Infof("Reloading config file: %v", ConfigFileUsed())
+			newRepositories, newAliases, newSchedule, err := reloadSettings()
+			if err != nil {
+				Log.Warnf("Error reloading config file, keeping the previous one: %v", err)
+			} else {
+				repositories, aliases, schedule = newRepositories, newAliases, newSchedule
+			}
+		}
+		Log.Infof("Evaluating repositories")
+		if err := run(ctx, client, repositories, aliases, schedule, options); err != nil {
+			if IsAuthError(err) {
+				return err
+			}
+			Log.Errorf("%v", err)
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		case <-time.After(jitter(interval)):
+		}
+	}
+}
+
+// reloadSettings reads the configuration file again and returns its settings.
+func reloadSettings() (*Config, [][]string, *Schedule, error) {
+	if err := ReadInConfig(); err != nil {
+		return nil, nil, nil, err
+	}
+	return loadSettings()
+}
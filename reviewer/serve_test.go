@@ -0,0 +1,44 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitter(t *testing.T) {
+	interval := 2 * time.Minute
+	for n := 0; n < 100; n++ {
+		wait := jitter(interval)
+		if wait < interval-interval/10 || wait > interval+interval/10 {
+			t.Fatalf("Jitter %v too far from %v", wait, interval)
+		}
+	}
+}
+
+func TestStopping(t *testing.T) {
+	stop := make(chan struct{})
+	if stopping(stop) {
+		t.Fatal("Open stop channel shouldn't be stopping")
+	}
+	if stopping(nil) {
+		t.Fatal("Nil stop channel shouldn't be stopping")
+	}
+	close(stop)
+	if !stopping(stop) {
+		t.Fatal("Closed stop channel should be stopping")
+	}
+}
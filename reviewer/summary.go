@@ -0,0 +1,101 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// SummaryMarker is the hidden mark identifying Reviewer's summary comment in a pull request.
+const SummaryMarker = "<!-- reviewer:summary -->"
+
+// SummaryComment returns the body of Reviewer's summary comment for a pull request.
+func SummaryComment(prInfo PullRequestInfo, required int, mergeable *bool, testsState string) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n### Reviewer summary\n\n", SummaryMarker)
+
+	counted := 0
+	for _, vote := range prInfo.Votes {
+		if vote.Reason == "" {
+			counted++
+		}
+	}
+	if counted == 0 {
+		buf.WriteString("No votes counted yet.\n")
+	} else {
+		buf.WriteString("Counted votes:\n\n")
+		for _, vote := range prInfo.Votes {
+			if vote.Reason == "" {
+				fmt.Fprintf(&buf, "- @%s %s\n", vote.Login, voteString(vote.Score))
+			}
+		}
+	}
+	if counted < len(prInfo.Votes) {
+		buf.WriteString("\nIgnored votes:\n\n")
+		for _, vote := range prInfo.Votes {
+			if vote.Reason != "" {
+				fmt.Fprintf(&buf, "- @%s %s (%s)\n", vote.Login, voteString(vote.Score), vote.Reason)
+			}
+		}
+	}
+
+	fmt.Fprintf(&buf, "\n**Score:** %v of %v required\n", prInfo.Score, required)
+	fmt.Fprintf(&buf, "**Tests:** %v\n", testsState)
+
+	missing := []string{}
+	if prInfo.Score < required {
+		missing = append(missing, fmt.Sprintf("%v more approval(s)", required-prInfo.Score))
+	}
+	if testsState != "success" {
+		missing = append(missing, "passing tests")
+	}
+	if mergeable == nil {
+		missing = append(missing, "mergeability check")
+	} else if !*mergeable {
+		missing = append(missing, "resolving merge conflicts")
+	}
+	if len(missing) == 0 {
+		buf.WriteString("**Missing:** nothing, ready to merge\n")
+	} else {
+		fmt.Fprintf(&buf, "**Missing:** %s\n", strings.Join(missing, ", "))
+	}
+	return buf.String()
+}
+
+// voteString returns the vote in words.
+func voteString(score int) string {
+	if score > 0 {
+		return "approved"
+	}
+	return "rejected"
+}
+
+// UpdateSummary posts Reviewer's summary comment, or edits it in place if it changed.
+func UpdateSummary(ctx context.Context, client *GHClient, owner string, repo string, prInfo PullRequestInfo, body string) error {
+	if prInfo.SummaryID == 0 {
+		_, _, err := client.Tickets.CreateComment(ctx, owner, repo, prInfo.Number, &github.IssueComment{Body: &body})
+		return err
+	}
+	if prInfo.SummaryBody == body {
+		return nil
+	}
+	_, _, err := client.Tickets.EditComment(ctx, owner, repo, prInfo.SummaryID, &github.IssueComment{Body: &body})
+	return err
+}
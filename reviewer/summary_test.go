@@ -0,0 +1,84 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSummaryComment(t *testing.T) {
+	mergeable := true
+	prInfo := PullRequestInfo{
+		Number: 47,
+		Title:  "Changes CI badge location on README.md",
+		Score:  1,
+		Votes: []Vote{
+			{Login: "reviewer1", Score: 1},
+			{Login: "stranger", Score: 1, Reason: VoteNotAllowed},
+		},
+	}
+
+	body := SummaryComment(prInfo, 3, &mergeable, "pending")
+	if !strings.HasPrefix(body, SummaryMarker) {
+		t.Fatal("Summary comment must start with the marker")
+	}
+	for _, expected := range []string{
+		"@reviewer1 approved",
+		"@stranger approved (not in allowed)",
+		"1 of 3 required",
+		"**Tests:** pending",
+		"2 more approval(s), passing tests",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Fatalf("Summary comment doesn't contain %q:\n%s", expected, body)
+		}
+	}
+
+	prInfo.Score = 3
+	body = SummaryComment(prInfo, 3, &mergeable, "success")
+	if !strings.Contains(body, "nothing, ready to merge") {
+		t.Fatalf("Summary comment should be ready to merge:\n%s", body)
+	}
+}
+
+func TestUpdateSummary(t *testing.T) {
+	client := newMockGHClient(nil, nil)
+	tickets := client.Tickets.(*mockTicketsService)
+
+	prInfo := PullRequestInfo{Number: 1}
+	if err := UpdateSummary(context.Background(), client, "user", "repo", prInfo, "new"); err != nil {
+		t.Fatalf("New summary returned error(%s)", err)
+	}
+	if tickets.created != 1 || tickets.edited != 0 {
+		t.Fatal("New summary should be posted")
+	}
+
+	prInfo = PullRequestInfo{Number: 1, SummaryID: 10, SummaryBody: "same"}
+	if err := UpdateSummary(context.Background(), client, "user", "repo", prInfo, "same"); err != nil {
+		t.Fatalf("Unchanged summary returned error(%s)", err)
+	}
+	if tickets.created != 1 || tickets.edited != 0 {
+		t.Fatal("Unchanged summary should be left untouched")
+	}
+
+	if err := UpdateSummary(context.Background(), client, "user", "repo", prInfo, "changed"); err != nil {
+		t.Fatalf("Changed summary returned error(%s)", err)
+	}
+	if tickets.created != 1 || tickets.edited != 1 {
+		t.Fatal("Changed summary should be edited in place")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Target selects a repository, or a single pull request of it, to be processed.
+type Target struct {
+	Owner  string
+	Repo   string
+	Number int    // pull request number, 0 for all of them
+	SHA    string // head commit of the pull requests, when the number is unknown
+}
+
+// ParseTargets returns the targets given like owner/repo or owner/repo#42.
+func ParseTargets(targets []string) ([]Target, error) {
+	result := make([]Target, 0, len(targets))
+	for _, target := range targets {
+		owner, repo, number, err := ParseTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, Target{Owner: owner, Repo: repo, Number: number})
+	}
+	return result, nil
+}
+
+// selectTargets returns whether the repository is selected by the targets, and the numbers of the pull requests selected,
+// none meaning all of them. Without targets, every repository is selected.
+func selectTargets(targets []Target, owner string, repo string) (bool, []int) {
+	if len(targets) == 0 {
+		return true, nil
+	}
+	selected := false
+	numbers := []int{}
+	for _, target := range targets {
+		if !strings.EqualFold(target.Owner, owner) || !strings.EqualFold(target.Repo, repo) {
+			continue
+		}
+		if target.Number == 0 {
+			return true, nil
+		}
+		selected = true
+		numbers = append(numbers, target.Number)
+	}
+	return selected, numbers
+}
+
+// ParseTarget returns the owner, repository and pull request number, 0 if not given, of a target like owner/repo or owner/repo#42.
+func ParseTarget(target string) (string, string, int, error) {
+	number := 0
+	if n := strings.Index(target, "#"); n >= 0 {
+		var err error
+		number, err = strconv.Atoi(target[n+1:])
+		if err != nil || number <= 0 {
+			return "", "", 0, fmt.Errorf("Invalid pull request number in %q", target)
+		}
+		target = target[:n]
+	}
+	parts := strings.Split(target, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", 0, fmt.Errorf("Invalid repository %q, expected owner/repo", target)
+	}
+	return parts[0], parts[1], number, nil
+}
+
+// findRepository returns the key of the repository in the configuration.
+func findRepository(config ConfigRepositoriesChecker, owner string, repo string) (string, error) {
+	for _, name := range RepositoryNames(config) {
+		if strings.EqualFold(name, repo) && strings.EqualFold(config.GetString(name+".username"), owner) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("Repository %v/%v not configured", owner, repo)
+}
@@ -0,0 +1,57 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	owner, repo, number, err := ParseTarget("cooldeveloper/mycoolapp#42")
+	if err != nil || owner != "cooldeveloper" || repo != "mycoolapp" || number != 42 {
+		t.Fatalf("Bad target %v/%v#%v (%v)", owner, repo, number, err)
+	}
+	owner, repo, number, err = ParseTarget("cooldeveloper/mycoolapp")
+	if err != nil || owner != "cooldeveloper" || repo != "mycoolapp" || number != 0 {
+		t.Fatalf("Bad target %v/%v#%v (%v)", owner, repo, number, err)
+	}
+	for _, target := range []string{"mycoolapp", "cooldeveloper/mycoolapp#", "cooldeveloper/mycoolapp#x", "/mycoolapp", "a/b/c"} {
+		if _, _, _, err = ParseTarget(target); err == nil {
+			t.Fatalf("Invalid target %q should return error", target)
+		}
+	}
+}
+
+func TestSelectTargets(t *testing.T) {
+	targets, err := ParseTargets([]string{"cooldeveloper/mycoolapp#42", "cooldeveloper/mycoolapp#43", "cooldeveloper/myevencoolapi"})
+	if err != nil {
+		t.Fatalf("ParseTargets returned error(%s)", err)
+	}
+
+	selected, numbers := selectTargets(targets, "cooldeveloper", "mycoolapp")
+	if !selected || len(numbers) != 2 || numbers[0] != 42 || numbers[1] != 43 {
+		t.Fatalf("mycoolapp should be selected for PRs 42 and 43, got %v %v", selected, numbers)
+	}
+	selected, numbers = selectTargets(targets, "CoolDeveloper", "myevencoolapi")
+	if !selected || len(numbers) != 0 {
+		t.Fatalf("myevencoolapi should be selected for all PRs, got %v %v", selected, numbers)
+	}
+	if selected, _ = selectTargets(targets, "cooldeveloper", "other"); selected {
+		t.Fatal("Repositories not in the targets shouldn't be selected")
+	}
+	if selected, _ = selectTargets(nil, "cooldeveloper", "other"); !selected {
+		t.Fatal("Without targets every repository should be selected")
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// SignPayload returns the X-Hub-Signature-256 header value GitHub sends for the payload.
+func SignPayload(secret []byte, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookPayload contains the fields of GitHub's event payloads needed to find the affected pull request.
+type webhookPayload struct {
+	Action     string `json:"action"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	PullRequest struct {
+		Number int    `json:"number"`
+		State  string `json:"state"`
+	} `json:"pull_request"`
+	Issue struct {
+		Number      int              `json:"number"`
+		State       string           `json:"state"`
+		PullRequest *json.RawMessage `json:"pull_request"`
+	} `json:"issue"`
+	SHA        string `json:"sha"`
+	CheckSuite struct {
+		HeadSHA      string `json:"head_sha"`
+		PullRequests []struct {
+			Number int `json:"number"`
+		} `json:"pull_requests"`
+	} `json:"check_suite"`
+}
+
+// eventTargets returns the pull requests affected by the event, given by number, or by their head commit when it's unknown.
+// Events of closed pull requests, including their closing, affect none, so merges don't trigger another evaluation.
+func eventTargets(event string, payload []byte) ([]Target, error) {
+	var p webhookPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(p.Repository.FullName, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("Invalid repository %q", p.Repository.FullName)
+	}
+	target := Target{Owner: parts[0], Repo: parts[1]}
+
+	switch event {
+	case "pull_request", "pull_request_review":
+		if p.Action == "closed" || (p.PullRequest.State != "" && p.PullRequest.State != "open") {
+			return nil, nil
+		}
+		target.Number = p.PullRequest.Number
+	case "issue_comment":
+		if p.Issue.PullRequest == nil || (p.Issue.State != "" && p.Issue.State != "open") {
+			return nil, nil
+		}
+		target.Number = p.Issue.Number
+	case "status":
+		target.SHA = p.SHA
+	case "check_suite":
+		if len(p.CheckSuite.PullRequests) == 0 {
+			target.SHA = p.CheckSuite.HeadSHA
+			break
+		}
+		targets := []Target{}
+		for _, pr := range p.CheckSuite.PullRequests {
+			targets = append(targets, Target{Owner: target.Owner, Repo: target.Repo, Number: pr.Number})
+		}
+		return targets, nil
+	default:
+		return nil, nil
+	}
+	if target.Number == 0 && target.SHA == "" {
+		return nil, fmt.Errorf("No pull request found in %v event", event)
+	}
+	return []Target{target}, nil
+}
+
+// MaxPayloadSize is the default size limit of the events' payloads, the one of GitHub.
+const MaxPayloadSize = 25 << 20
+
+// WebhookHandler is an http.Handler receiving GitHub events, and processing the pull requests affected by them.
+type WebhookHandler struct {
+	Secret         []byte                 // to validate the X-Hub-Signature-256 header
+	Process        func(targets []Target) // called with the affected pull requests
+	MaxPayloadSize int64                  // larger payloads are rejected, MaxPayloadSize when 0
+}
+
+// ServeHTTP validates the event's size and signature, and processes the affected pull requests.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := h.MaxPayloadSize
+	if limit == 0 {
+		limit = MaxPayloadSize
+	}
+	// limited before checking the signature, so unauthenticated callers can't exhaust the memory
+	if r.ContentLength > limit {
+		http.Error(w, "Payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil && int64(len(payload)) >= limit {
+		http.Error(w, "Payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error reading payload", http.StatusBadRequest)
+		return
+	}
+	if !hmac.Equal([]byte(r.Header.Get("X-Hub-Signature-256")), []byte(SignPayload(h.Secret, payload))) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+	event := r.Header.Get("X-GitHub-Event")
+	if event == "ping" {
+		fmt.Fprintf(w, "pong\n")
+		return
+	}
+	targets, err := eventTargets(event, payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(w, "Ignored %v event\n", event)
+		return
+	}
+	h.Process(targets)
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "Processing %v event\n", event)
+}
+
+// resolveTargets returns the targets with the ones given by their head commit replaced by the open pull requests having it.
+func resolveTargets(ctx context.Context, client *GHClient, targets []Target) ([]Target, error) {
+	resolved := []Target{}
+	for _, target := range targets {
+		if target.SHA == "" {
+			resolved = append(resolved, target)
+			continue
+		}
+		numbers, err := pullRequestsWithHead(ctx, client, target.Owner, target.Repo, target.SHA)
+		if err != nil {
+			return nil, err
+		}
+		for _, number := range numbers {
+			resolved = append(resolved, Target{Owner: target.Owner, Repo: target.Repo, Number: number})
+		}
+	}
+	return resolved, nil
+}
+
+// pullRequestsWithHead returns the numbers of the open pull requests whose head is the commit, going through every page.
+func pullRequestsWithHead(ctx context.Context, client *GHClient, owner string, repo string, sha string) ([]int, error) {
+	options := &github.PullRequestListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var numbers []int
+	for {
+		pullRequests, response, err := client.Changes.List(ctx, owner, repo, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, pullRequest := range pullRequests {
+			if pullRequest.Head != nil && pullRequest.Head.SHA != nil && *pullRequest.Head.SHA == sha {
+				numbers = append(numbers, *pullRequest.Number)
+			}
+		}
+		if response == nil || response.NextPage == 0 {
+			return numbers, nil
+		}
+		options.Page = response.NextPage
+	}
+}
+
+// MaxQueuedTargets is the maximum number of pull requests waiting to be processed, events for more are dropped.
+const MaxQueuedTargets = 1000
+
+// eventQueue holds the pull requests waiting to be processed, each once however many events affected it meanwhile.
+type eventQueue struct {
+	mutex   sync.Mutex
+	targets []Target
+	queued  map[Target]bool
+	limit   int
+	ready   chan struct{} // receives when there are targets
+}
+
+// newEventQueue returns an empty queue holding up to limit targets.
+func newEventQueue(limit int) *eventQueue {
+	return &eventQueue{
+		queued: make(map[Target]bool),
+		limit:  limit,
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+// Add queues the targets not queued yet, and returns the ones dropped because the queue is full.
+func (q *eventQueue) Add(targets []Target) []Target {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	var dropped []Target
+	for _, target := range targets {
+		if q.queued[target] {
+			continue
+		}
+		if len(q.targets) >= q.limit {
+			dropped = append(dropped, target)
+			continue
+		}
+		q.queued[target] = true
+		q.targets = append(q.targets, target)
+	}
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return dropped
+}
+
+// Take returns the queued targets, emptying the queue.
+func (q *eventQueue) Take() []Target {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	targets := q.targets
+	q.targets = nil
+	q.queued = make(map[Target]bool)
+	return targets
+}
+
+// Webhook listens for GitHub events on the address, re-evaluating the affected pull requests as Execute does.
+// The events are validated with the secret in webhook.secret, and their pull requests queued, each once,
+// and processed one at a time.
+// On SIGINT or SIGTERM, or when the context is done, it stops listening and returns after finishing the pull request
+// being processed, see StopOnSignal. It also returns when the address can't be listened on.
+func Webhook(ctx context.Context, listen string, options Options) error {
+	if options.DryRun {
+		Log.Infof("Working in dry-run mode")
+	}
+	secret := GetString("webhook.secret")
+	if secret == "" {
+		return ConfigError{errors.New("webhook.secret not set")}
+	}
+	repositories, aliases, schedule, client, err := loadConfiguration()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := StopOnSignal(cancel)
+	options.Stop = stop
+
+	queue := newEventQueue(MaxQueuedTargets)
+	server := &http.Server{
+		Addr: listen,
+		Handler: &WebhookHandler{
+			Secret: []byte(secret),
+			Process: func(targets []Target) {
+				Log.Debugf("Queueing %v", targets)
+				if dropped := queue.Add(targets); len(dropped) > 0 {
+					Log.Warnf("Too many pull requests queued, dropping %v", dropped)
+				}
+			},
+		},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-queue.ready:
+			}
+			processTargets(ctx, client, repositories, aliases, schedule, options, queue.Take())
+		}
+	}()
+
+	listening := make(chan error, 1)
+	go func() {
+		Log.Infof("Listening on %v", listen)
+		listening <- server.ListenAndServe()
+	}()
+	select {
+	case err = <-listening:
+		cancel()
+	case <-stop:
+	case <-ctx.Done():
+	}
+	if shutdownErr := server.Shutdown(context.Background()); err == nil && shutdownErr != nil {
+		err = shutdownErr
+	}
+	<-done
+	if pending := queue.Take(); len(pending) > 0 {
+		Log.Warnf("Stopped with pull requests not processed: %v", pending)
+	}
+	return err
+}
+
+// processTargets evaluates the pull requests affected by events as Execute does, finding the ones given by their head commit first.
+func processTargets(ctx context.Context, client *GHClient, repositories *Config, aliases [][]string, schedule *Schedule, options Options, targets []Target) {
+	Log.Debugf("Processing %v", targets)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+	resolved, err := resolveTargets(ctx, client, targets)
+	if err != nil {
+		Log.Errorf("Error finding pull requests: %v", err)
+		return
+	}
+	if len(resolved) == 0 {
+		return
+	}
+	options.Targets = resolved
+	if err := run(ctx, client, repositories, aliases, schedule, options); err != nil {
+		Log.Errorf("%v", err)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright © 2016 See CONTRIBUTORS <ignasi.fosch@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewer
+
+import (
+	"bytes"
+	"context"
+	"github.com/google/go-github/v32/github"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sendEvent sends the event to the handler like GitHub does, signed with the secret.
+func sendEvent(handler http.Handler, secret string, event string, payload string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(payload))
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-Hub-Signature-256", SignPayload([]byte(secret), []byte(payload)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebhookHandler(t *testing.T) {
+	var got []Target
+	handler := &WebhookHandler{
+		Secret:  []byte("s3cr3t"),
+		Process: func(targets []Target) { got = targets },
+	}
+	repo := `"repository": {"full_name": "cooldeveloper/mycoolapp"}`
+	tests := []struct {
+		event   string
+		payload string
+		code    int
+		targets []Target
+	}{
+		{"pull_request", `{"action": "opened", "pull_request": {"number": 42}, ` + repo + `}`, http.StatusAccepted,
+			[]Target{{Owner: "cooldeveloper", Repo: "mycoolapp", Number: 42}}},
+		{"pull_request", `{"action": "closed", "pull_request": {"number": 42, "state": "closed", "merged": true}, ` + repo + `}`, http.StatusOK, nil},
+		{"pull_request_review", `{"pull_request": {"number": 7}, ` + repo + `}`, http.StatusAccepted,
+			[]Target{{Owner: "cooldeveloper", Repo: "mycoolapp", Number: 7}}},
+		{"pull_request_review", `{"action": "submitted", "pull_request": {"number": 7, "state": "closed"}, ` + repo + `}`, http.StatusOK, nil},
+		{"issue_comment", `{"issue": {"number": 3, "state": "closed", "pull_request": {}}, ` + repo + `}`, http.StatusOK, nil},
+		{"issue_comment", `{"issue": {"number": 3, "pull_request": {}}, ` + repo + `}`, http.StatusAccepted,
+			[]Target{{Owner: "cooldeveloper", Repo: "mycoolapp", Number: 3}}},
+		{"issue_comment", `{"issue": {"number": 3}, ` + repo + `}`, http.StatusOK, nil},
+		{"status", `{"sha": "abc123", ` + repo + `}`, http.StatusAccepted,
+			[]Target{{Owner: "cooldeveloper", Repo: "mycoolapp", SHA: "abc123"}}},
+		{"check_suite", `{"check_suite": {"head_sha": "abc123", "pull_requests": [{"number": 1}, {"number": 2}]}, ` + repo + `}`, http.StatusAccepted,
+			[]Target{{Owner: "cooldeveloper", Repo: "mycoolapp", Number: 1}, {Owner: "cooldeveloper", Repo: "mycoolapp", Number: 2}}},
+		{"ping", `{"zen": "Keep it logically awesome."}`, http.StatusOK, nil},
+		{"push", `{"ref": "refs/heads/master", ` + repo + `}`, http.StatusOK, nil},
+		{"pull_request", `{"action": "opened", ` + repo + `}`, http.StatusBadRequest, nil},
+		{"pull_request", `not json`, http.StatusBadRequest, nil},
+	}
+	for _, test := range tests {
+		got = nil
+		rec := sendEvent(handler, "s3cr3t", test.event, test.payload)
+		if rec.Code != test.code {
+			t.Errorf("%v %v: expected code %v, got %v", test.event, test.payload, test.code, rec.Code)
+		}
+		if len(got) != len(test.targets) {
+			t.Errorf("%v %v: expected targets %v, got %v", test.event, test.payload, test.targets, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.targets[i] {
+				t.Errorf("%v %v: expected target %v, got %v", test.event, test.payload, test.targets[i], got[i])
+			}
+		}
+	}
+}
+
+func TestWebhookHandlerSignature(t *testing.T) {
+	processed := false
+	handler := &WebhookHandler{
+		Secret:  []byte("s3cr3t"),
+		Process: func(targets []Target) { processed = true },
+	}
+	payload := `{"pull_request": {"number": 42}, "repository": {"full_name": "cooldeveloper/mycoolapp"}}`
+	if rec := sendEvent(handler, "wrong", "pull_request", payload); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected code %v for a wrong signature, got %v", http.StatusUnauthorized, rec.Code)
+	}
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(payload))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected code %v for a missing signature, got %v", http.StatusUnauthorized, rec.Code)
+	}
+	if processed {
+		t.Error("Events with invalid signatures shouldn't be processed")
+	}
+}
+
+func TestWebhookHandlerPayloadSize(t *testing.T) {
+	processed := false
+	handler := &WebhookHandler{
+		Secret:         []byte("s3cr3t"),
+		Process:        func(targets []Target) { processed = true },
+		MaxPayloadSize: 64,
+	}
+	payload := `{"pull_request": {"number": 42}, "repository": {"full_name": "cooldeveloper/mycoolapp"}}`
+	if rec := sendEvent(handler, "s3cr3t", "pull_request", payload); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected code %v for a large payload, got %v", http.StatusRequestEntityTooLarge, rec.Code)
+	}
+	req, _ := http.NewRequest("POST", "/", struct{ io.Reader }{strings.NewReader(payload)})
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected code %v for a large payload of unknown length, got %v", http.StatusRequestEntityTooLarge, rec.Code)
+	}
+	if processed {
+		t.Error("Events with large payloads shouldn't be processed")
+	}
+}
+
+func TestResolveTargets(t *testing.T) {
+	number1, number2 := 1, 2
+	sha1, sha2 := "abc123", "def456"
+	changes := newMockChangesService([]*github.PullRequest{
+		{Number: &number1, Head: &github.PullRequestBranch{SHA: &sha1}},
+		{Number: &number2, Head: &github.PullRequestBranch{SHA: &sha2}},
+	})
+	changes.listPerPage = 1
+	client := &GHClient{Changes: changes}
+	targets := []Target{
+		{Owner: "cooldeveloper", Repo: "mycoolapp", Number: 7},
+		{Owner: "cooldeveloper", Repo: "mycoolapp", SHA: "def456"},
+		{Owner: "cooldeveloper", Repo: "mycoolapp", SHA: "unknown"},
+	}
+	resolved, err := resolveTargets(context.Background(), client, targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Target{
+		{Owner: "cooldeveloper", Repo: "mycoolapp", Number: 7},
+		{Owner: "cooldeveloper", Repo: "mycoolapp", Number: 2},
+	}
+	if len(resolved) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, resolved)
+	}
+	for i := range resolved {
+		if resolved[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], resolved[i])
+		}
+	}
+}
+
+func TestEventQueue(t *testing.T) {
+	queue := newEventQueue(2)
+	first := Target{Owner: "cooldeveloper", Repo: "mycoolapp", Number: 1}
+	second := Target{Owner: "cooldeveloper", Repo: "mycoolapp", Number: 2}
+	third := Target{Owner: "cooldeveloper", Repo: "mycoolapp", Number: 3}
+	if dropped := queue.Add([]Target{first}); len(dropped) != 0 {
+		t.Fatalf("Expected nothing dropped, got %v", dropped)
+	}
+	if dropped := queue.Add([]Target{first, second, third}); len(dropped) != 1 || dropped[0] != third {
+		t.Fatalf("Expected %v dropped from the full queue, got %v", third, dropped)
+	}
+	select {
+	case <-queue.ready:
+	default:
+		t.Fatal("The queue should be ready after adding targets")
+	}
+	if targets := queue.Take(); len(targets) != 2 || targets[0] != first || targets[1] != second {
+		t.Fatalf("Expected each target queued once, got %v", targets)
+	}
+	if dropped := queue.Add([]Target{first}); len(dropped) != 0 {
+		t.Fatalf("Taken targets should be queued again, got %v dropped", dropped)
+	}
+}